@@ -18,7 +18,10 @@ package emul8
 
 import (
 	"context"
+	"math"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/go-audio/audio"
 	"github.com/go-audio/generator"
@@ -31,25 +34,140 @@ const (
 	note       float64 = 440.0
 )
 
+// DefaultEnvelope is the attack and release time of a Beep, long enough to
+// keep the buzzer from clicking as the sound timer switches it on and off.
+const DefaultEnvelope time.Duration = 5 * time.Millisecond
+
 var (
 	format = audio.FormatMono44100
 )
 
+// Beep plays the sound timer buzzer. It is driven from the CPU loop, which
+// must never wait on the audio device, so Start and Stop only signal a
+// goroutine that owns the device. That goroutine is launched by the first
+// Start and keeps the stream open, writing silence between beeps, until Close.
+// A stalled device therefore stalls the goroutine alone.
+//
+// The tone is a 440Hz sine, unless SetPattern gives a sample loop to play in
+// its place.
+//
+// Start, Stop, and SetPattern may be called from any goroutine.
 type Beep struct {
+	// Attack and Release are how long the tone takes to ramp linearly up to
+	// full volume when started, and back down to silence when stopped. Zero
+	// means DefaultEnvelope, and a negative duration starts or stops the tone
+	// abruptly.
+	Attack  time.Duration
+	Release time.Duration
+
 	g       errgroup.Group
+	launch  sync.Once
+	cancel  context.CancelFunc
 	beeping atomic.Bool
+
+	mu      sync.Mutex
+	err     error  // Failure of the audio goroutine, not yet reported.
+	pattern []byte // Sample loop set by SetPattern, one bit per sample.
+	rate    float64
 }
 
-func (b *Beep) Start(ctx context.Context) error {
-	if b.beeping.Load() {
-		return nil
+// SetPattern replaces the tone with pattern, a loop of one bit samples, most
+// significant bit first, played at rate samples per second, as
+// chip8.Processor.AudioPattern returns it. An empty pattern or a rate that is
+// not positive restores the 440Hz tone. The change is heard from the next
+// buffer written to the device.
+func (b *Beep) SetPattern(pattern []byte, rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(pattern) == 0 || rate <= 0 {
+		b.pattern, b.rate = nil, 0
+		return
+	}
+	b.pattern = append(b.pattern[:0], pattern...)
+	b.rate = rate
+}
+
+// fillPattern fills dst with the loop set by SetPattern, continuing from the bit
+// position pos, and returns the position after the last sample. It returns
+// false, leaving dst untouched, if there is no loop set.
+func (b *Beep) fillPattern(dst []float64, pos float64) (float64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pattern == nil {
+		return 0, false
 	}
+
+	bits := float64(len(b.pattern) * 8)
+	step := b.rate / float64(format.SampleRate)
+	pos = math.Mod(pos, bits)
+	for i := range dst {
+		bit := int(pos)
+		if b.pattern[bit/8]&(0x80>>(bit%8)) != 0 {
+			dst[i] = 1
+		} else {
+			dst[i] = -1
+		}
+		if pos += step; pos >= bits {
+			pos -= bits
+		}
+	}
+	return pos, true
+}
+
+// Start sounds the tone, launching the audio goroutine on first use. The
+// goroutine lives until ctx is done or Close is called. It returns the error
+// the audio goroutine failed with, if it has, and reports each failure once.
+func (b *Beep) Start(ctx context.Context) error {
 	b.beeping.Store(true)
+	b.launch.Do(func() {
+		ctx, b.cancel = context.WithCancel(ctx)
+		b.g.Go(func() error {
+			err := b.play(ctx)
+			if err != nil {
+				b.mu.Lock()
+				b.err = err
+				b.mu.Unlock()
+			}
+			return err
+		})
+	})
+	return b.failure()
+}
 
-	err := portaudio.Initialize()
-	if err != nil {
+// Stop silences the tone, fading it out over the Release. Like Start, it
+// returns an unreported failure of the audio goroutine.
+func (b *Beep) Stop() error {
+	b.beeping.Store(false)
+	return b.failure()
+}
+
+// Close stops the audio goroutine and waits for it to release the device. It
+// returns an unreported failure of the goroutine.
+func (b *Beep) Close() error {
+	b.beeping.Store(false)
+	b.launch.Do(func() {}) // Prevent a later Start from launching.
+	if b.cancel != nil {
+		b.cancel()
+	}
+	_ = b.g.Wait()
+	return b.failure()
+}
+
+func (b *Beep) failure() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.err
+	b.err = nil
+	return err
+}
+
+func (b *Beep) play(ctx context.Context) error {
+	if err := portaudio.Initialize(); err != nil {
 		return err
 	}
+	defer func() {
+		_ = portaudio.Terminate()
+	}()
 
 	buffer := &audio.FloatBuffer{
 		Data:   make([]float64, bufferSize),
@@ -59,52 +177,73 @@ func (b *Beep) Start(ctx context.Context) error {
 	osc := generator.NewOsc(generator.WaveSine, note, buffer.Format.SampleRate)
 	osc.Amplitude = 1
 
-	b.g.Go(func() error {
-		defer func() {
-			_ = portaudio.Terminate()
-		}()
+	attack, release := envelopeSamples(b.Attack), envelopeSamples(b.Release)
 
-		out := make([]float32, bufferSize)
+	out := make([]float32, bufferSize)
 
-		stream, err := portaudio.OpenDefaultStream(0, 1, 44100, len(out), &out)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			_ = stream.Close()
-		}()
+	stream, err := portaudio.OpenDefaultStream(0, 1, 44100, len(out), &out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
 
-		if err := stream.Start(); err != nil {
-			return err
-		}
-		defer func() {
-			_ = stream.Stop()
-		}()
+	if err := stream.Start(); err != nil {
+		return err
+	}
+	defer func() {
+		_ = stream.Stop()
+	}()
 
-		for b.beeping.Load() && ctx.Err() == nil {
+	gain, pos := 0.0, 0.0
+	for ctx.Err() == nil {
+		beeping := b.beeping.Load()
+
+		var ok bool
+		if pos, ok = b.fillPattern(buffer.Data, pos); !ok {
 			if err := osc.Fill(buffer); err != nil {
 				return err
 			}
+		}
 
-			f64Tof32(out, buffer.Data)
-
-			if err := stream.Write(); err != nil {
-				return err
+		for i := range buffer.Data {
+			switch {
+			case beeping && gain < 1:
+				if attack == 0 {
+					gain = 1
+				} else {
+					gain = min(gain+1/float64(attack), 1)
+				}
+			case !beeping && gain > 0:
+				if release == 0 {
+					gain = 0
+				} else {
+					gain = max(gain-1/float64(release), 0)
+				}
 			}
+			buffer.Data[i] *= gain
 		}
 
-		return nil
-	})
+		f64Tof32(out, buffer.Data)
+
+		if err := stream.Write(); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func (b *Beep) Stop() error {
-	if !b.beeping.Load() {
-		return nil
+// envelopeSamples converts an envelope duration to a number of samples.
+func envelopeSamples(d time.Duration) int {
+	if d == 0 {
+		d = DefaultEnvelope
 	}
-	b.beeping.Store(false)
-	return b.g.Wait()
+	if d < 0 {
+		return 0
+	}
+	return int(d.Seconds() * float64(format.SampleRate))
 }
 
 func f64Tof32(dst []float32, src []float64) {
@@ -19,3 +19,7 @@ func U16tob(i uint16) []byte {
 	b[1] = byte(i)
 	return b[:]
 }
+
+func Btou16(b []byte) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"math"
+	"slices"
+)
+
+const (
+	// AudioPatternSize is the size of the XO-CHIP audio pattern, which holds
+	// one bit per sample.
+	AudioPatternSize int = 16
+
+	// DefaultPitch is the pitch before FX3A sets one, at which the audio
+	// pattern plays at 4000 samples per second.
+	DefaultPitch uint8 = 64
+)
+
+// AudioPattern returns the audio pattern an XO-CHIP ROM loaded with F002, and
+// the rate in samples per second at which it plays, which follows the pitch set
+// with FX3A. The pattern is AudioPatternSize bytes of one bit samples, most
+// significant bit first, which repeat for as long as the sound timer runs. It
+// returns nil and 0 until a pattern is loaded, in which case the host plays its
+// usual tone.
+func (p *Processor) AudioPattern() ([]byte, float64) {
+	if !p.hasPattern {
+		return nil, 0
+	}
+	return slices.Clone(p.pattern[:]), 4000 * math.Exp2((float64(p.pitch)-64)/48)
+}
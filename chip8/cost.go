@@ -0,0 +1,100 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"maps"
+	"time"
+)
+
+// VIPMachineCycle is the duration of one machine cycle of the RCA 1802 in the
+// COSMAC VIP, eight periods of its 1.76MHz clock.
+const VIPMachineCycle time.Duration = 4540 * time.Nanosecond
+
+// CostTable gives the number of hardware cycles each instruction takes,
+// keyed by the Pattern of its OpcodeSpec, such as "8XY4". Instructions that
+// are not listed cost one cycle.
+type CostTable map[string]uint32
+
+// VIPCosts returns the approximate cost of each instruction on the COSMAC VIP,
+// in VIP machine cycles, averaged over typical operands and rounded. The caller
+// owns the table and may edit it. The time DXYN spends waiting for the vertical
+// blank is not included; the DisplayWait quirk models that separately.
+func VIPCosts() CostTable {
+	return maps.Clone(vipCosts)
+}
+
+var vipCosts = CostTable{
+	"00E0": 24,
+	"00EE": 23,
+	"1NNN": 23,
+	"2NNN": 23,
+	"3XNN": 12,
+	"4XNN": 12,
+	"5XY0": 16,
+	"6XNN": 6,
+	"7XNN": 10,
+	"8XY0": 44,
+	"8XY1": 44,
+	"8XY2": 44,
+	"8XY3": 44,
+	"8XY4": 44,
+	"8XY5": 44,
+	"8XY6": 44,
+	"8XY7": 44,
+	"8XYE": 44,
+	"9XY0": 16,
+	"ANNN": 12,
+	"BNNN": 23,
+	"CXNN": 36,
+	"DXYN": 700,
+	"EX9E": 16,
+	"EXA1": 16,
+	"FX07": 10,
+	"FX0A": 16,
+	"FX15": 10,
+	"FX18": 10,
+	"FX1E": 19,
+	"FX29": 20,
+	"FX33": 204,
+	"FX55": 133,
+	"FX65": 133,
+}
+
+// cost returns the cost of op under the Costs table.
+func (p *Processor) cost(op Opcode) uint32 {
+	if p.Costs == nil {
+		return 1
+	}
+
+	s, ok := specFor(op)
+	if !ok {
+		return 1
+	}
+
+	if c, ok := p.Costs[s.Pattern]; ok {
+		return c
+	}
+	return 1
+}
+
+// LastInstructionCost returns the cost of the instruction executed by the last
+// Step, in the units of the Costs table. Without a table every instruction
+// costs one cycle, the flat model in which all instructions take equally long.
+func (p *Processor) LastInstructionCost() uint32 {
+	return p.lastCost
+}
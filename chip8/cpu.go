@@ -17,14 +17,23 @@
 package chip8
 
 import (
+	"fmt"
+	"image/color"
+	"io"
+	"log/slog"
+	"maps"
+	"runtime"
+	"slices"
 	"sync/atomic"
 	"time"
 )
 
 const (
 	RegisterCount       int    = 16
+	FlagCount           int    = 8 // SUPER-CHIP's RPL user flags.
 	KeyCount            int    = 16
 	FontStartAddress    uint16 = 0x50
+	BigFontStartAddress uint16 = 0xA0 // Right after the small font.
 	LastAddress         uint16 = 0xFFE
 	ProgramStartAddress uint16 = 0x200
 	CarryFlag           uint8  = 0xF
@@ -32,9 +41,14 @@ const (
 	TimerRate time.Duration = time.Second / 60  // 60hz
 	ClockRate time.Duration = time.Second / 700 // 700hz
 
-	Width  int = 64
-	Height int = 32
-	Area   int = Width * Height
+	// The display is Width by Height pixels, unless SUPER-CHIP's high
+	// resolution mode is on, in which case it is HiResWidth by HiResHeight.
+	Width       int = 64
+	Height      int = 32
+	Area        int = Width * Height
+	HiResWidth  int = 128
+	HiResHeight int = 64
+	HiResArea   int = HiResWidth * HiResHeight
 )
 
 const (
@@ -43,8 +57,6 @@ const (
 	Redraw
 )
 
-var buffer [2]byte
-
 var fontSet = []byte{
 	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
 	0x20, 0x60, 0x20, 0x20, 0x70, // 1
@@ -64,11 +76,137 @@ var fontSet = []byte{
 	0xF0, 0x80, 0xF0, 0x80, 0x80, // F
 }
 
+// bigFontSet is SUPER-CHIP's 8x10 font for high resolution, pointed at by
+// Fx30. SUPER-CHIP 1.1 only had the digits 0 to 9; A to F are those XO-CHIP
+// interpreters added.
+var bigFontSet = []byte{
+	0x3C, 0x7E, 0xE7, 0xC3, 0xC3, 0xC3, 0xC3, 0xE7, 0x7E, 0x3C, // 0
+	0x18, 0x38, 0x58, 0x18, 0x18, 0x18, 0x18, 0x18, 0x18, 0x3C, // 1
+	0x3E, 0x7F, 0xC3, 0x06, 0x0C, 0x18, 0x30, 0x60, 0xFF, 0xFF, // 2
+	0x3C, 0x7E, 0xC3, 0x03, 0x0E, 0x0E, 0x03, 0xC3, 0x7E, 0x3C, // 3
+	0x06, 0x0E, 0x1E, 0x36, 0x66, 0xC6, 0xFF, 0xFF, 0x06, 0x06, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFC, 0xFE, 0x03, 0xC3, 0x7E, 0x3C, // 5
+	0x3E, 0x7C, 0xE0, 0xC0, 0xFC, 0xFE, 0xC3, 0xC3, 0x7E, 0x3C, // 6
+	0xFF, 0xFF, 0x03, 0x06, 0x0C, 0x18, 0x30, 0x60, 0x60, 0x60, // 7
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7E, 0x7E, 0xC3, 0xC3, 0x7E, 0x3C, // 8
+	0x3C, 0x7E, 0xC3, 0xC3, 0x7F, 0x3F, 0x03, 0x03, 0x3E, 0x7C, // 9
+	0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+	0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+	0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+}
+
 type Processor struct {
-	memory          [4096]byte
+	// TraceWriter, when set, receives one line per executed instruction in
+	// the format described by TraceEntry.String.
+	TraceWriter io.Writer
+
+	// Logger, when set, receives warnings about ROM behavior the processor
+	// tolerates, such as writes past the end of memory and clipped sprites.
+	Logger *slog.Logger
+
+	// SpriteGuard, when nonzero, makes Dxyn report through the Logger any
+	// sprite read from below this address, typically ProgramStartAddress.
+	// Reading font glyphs is exempt. Such reads almost always mean the ROM
+	// set I incorrectly, but they are otherwise executed as usual.
+	SpriteGuard uint16
+
+	// Permissive makes unknown opcodes execute as no-ops instead of faulting.
+	// Each one is recorded, and can be listed with UnhandledOpcodes.
+	Permissive bool
+
+	// CodeGuard, when nonzero, makes writes through Write, Fx33, and Fx55 that
+	// land within this many bytes of the executing instruction report through
+	// the Logger. Self-modifying code is rare, so such writes usually mean I
+	// points somewhere unintended. It is a heuristic: ROMs that keep variables
+	// right next to their code, or that really do modify themselves, are
+	// reported as well.
+	CodeGuard uint16
+
+	// Faults lists addresses that behave as faulty memory, for testing how a
+	// host handles faults. Faulty memory is not part of CHIP-8; it is a
+	// debugging aid and is empty normally. An instruction fetched from, or an
+	// instruction that reads or writes, a faulty address faults with an error
+	// wrapping ErrMemoryFault. Read and Write stop short at the first faulty
+	// address. Load and Reset are not affected.
+	Faults []uint16
+
+	// Rand, when set, is the source of the random numbers of CXNN, such as a
+	// Sequence in tests. Nil means the global source of math/rand/v2.
+	Rand RandSource
+
+	// Costs, when set, gives each instruction a cost in hardware cycles, which
+	// LastInstructionCost reports so that a host can pace execution the way
+	// the real hardware did, for instance with VIPCosts. Nil means the flat
+	// model, in which every instruction costs one cycle.
+	Costs CostTable
+
+	// StrictAlignment makes Step fail with an error wrapping ErrMisalignedPC
+	// when the program counter is odd, instead of fetching the opcode that
+	// straddles two instructions. Instructions are two bytes long, so an odd
+	// program counter almost always means a jump went astray.
+	StrictAlignment bool
+
+	// DrawMode selects how sprites are combined with the display. In every
+	// mode, VF reports whether a sprite turned off a lit pixel. The zero value
+	// is DrawXOR, the only mode CHIP-8 actually has.
+	DrawMode DrawMode
+
+	// PackedDisplay stores the display at one bit per pixel rather than one
+	// byte, which makes clearing it cheaper but drawing sprites somewhat
+	// slower. Display and the other accessors unpack it on each call. Set it
+	// before the first draw, since the two forms do not share their contents.
+	PackedDisplay bool
+
+	// XOChip enables the XO-CHIP display of two bitplanes, which FN01 selects
+	// for the instructions that draw, clear, and scroll to apply to, and 16x16
+	// sprites for DXY0 at either resolution. A pixel value then holds one bit
+	// per plane, from 0 to 3. It also grows memory to XOChipMemorySize, all of
+	// which F000 NNNN can point I at, and ROMs may fill up to the end, and
+	// lets ROMs replace the tone with an audio pattern of their own. It is
+	// off for classic ROMs, which only ever use the first plane and 4KB of
+	// memory, and for which FN01, F000, F002, and FX3A are unknown opcodes. Set
+	// it before Load.
+	XOChip bool
+
+	// Palette colors the images returned by Frame, indexed by pixel value.
+	// Nil means DefaultPalette.
+	Palette color.Palette
+
+	quirks    Quirks
+	clock     func() time.Time
+	clockRate time.Duration // Set with SetClockRate, zero for ClockRate.
+	timerRate time.Duration // Set with SetTimerRate, zero for TimerRate.
+	frameHook func(display []byte, w, h int)
+	flagsHook func(flags [FlagCount]byte)
+	tracer    func(addr uint16, op Opcode, mnemonic string)
+
+	breakpoints map[uint16]struct{} // Set with AddBreakpoint.
+	watched     uint16              // Bit x is set while Vx is watched.
+
+	// The RPL user flags stand in for the HP48's, which outlive the program,
+	// so they are not part of the machine state.
+	rpl [FlagCount]byte
+
+	machine
+
+	keyMask atomic.Uint32 // Bit k is set while key k is held.
+}
+
+// machine is the state of the emulated hardware, other than the keypad, which
+// Reset clears and Clone copies. Configuration lives on Processor instead.
+type machine struct {
+	memory          [XOChipMemorySize]byte
 	v               [RegisterCount]byte
-	keyState        [KeyCount]atomic.Bool
-	display         [Area]byte
+	display         [HiResArea]byte
+	packed          [Planes][HiResArea / 8]byte
+	hires           bool
+	planes          byte // Selected by FN01, one bit per plane.
+	pattern         [AudioPatternSize]byte
+	hasPattern      bool // Whether F002 has loaded pattern.
+	pitch           uint8
 	stack           [16]uint16
 	sp              uint8
 	pc              uint16
@@ -76,9 +214,19 @@ type Processor struct {
 	delay           uint8
 	sound           uint8
 	lastTimerUpdate time.Time
+	timerDrift      uint64
+	unhandledOps    []UnhandledOpcode
+	jumped          bool // Whether the executing instruction set the PC.
+	drawn           bool // Whether a sprite was drawn since the last timer tick.
+	lastCost        uint32
 }
 
+// Execute executes op without fetching it, adding its info bits to info. It
+// panics on a fault, which Step returns as an *OpcodeError instead.
 func (p *Processor) Execute(op Opcode, info *uint8) {
+	// The switch on the high nibble compiles to a jump table. Checking for the
+	// 00E0, Dxyn, and 1NNN that dominate most game loops ahead of it was
+	// measured and made no difference, so every opcode takes the same path.
 	switch op.kind() {
 	case 0x0:
 		switch uint16(op) {
@@ -86,8 +234,23 @@ func (p *Processor) Execute(op Opcode, info *uint8) {
 			p.clearScreen(info)
 		case 0x00EE:
 			p.returnFromSubroutine()
+		case 0x00FB:
+			p.scrollRight(info)
+		case 0x00FC:
+			p.scrollLeft(info)
+		case 0x00FE:
+			p.setHighRes(false, info)
+		case 0x00FF:
+			p.setHighRes(true, info)
 		default:
-			panic("unknown 0x0 opcode")
+			switch uint16(op) & 0xFFF0 {
+			case 0x00C0:
+				p.scrollDown(op.n(), info)
+			case 0x00D0:
+				p.scrollUp(op.n(), info)
+			default:
+				p.unhandled(op)
+			}
 		}
 	case 0x1:
 		p.jumpToLocation(op.nnn())
@@ -118,13 +281,13 @@ func (p *Processor) Execute(op Opcode, info *uint8) {
 		case 0x5:
 			p.subtractYFromX(op.x(), op.y())
 		case 0x6:
-			p.shiftRightX(op.x())
+			p.shiftRightX(op.x(), op.y())
 		case 0x7:
 			p.subtractXFromY(op.x(), op.y())
 		case 0xE:
-			p.shiftLeftX(op.x())
+			p.shiftLeftX(op.x(), op.y())
 		default:
-			panic("unknown 0x8 opcode")
+			p.unhandled(op)
 		}
 	case 0x9:
 		p.stepIfXNotEqualsY(op.x(), op.y())
@@ -143,10 +306,28 @@ func (p *Processor) Execute(op Opcode, info *uint8) {
 		case 0xA1:
 			p.stepIfKeyUp(op.x())
 		default:
-			panic("unknown 0xE opcode")
+			p.unhandled(op)
 		}
 	case 0xF:
 		switch op.nn() {
+		case 0x00:
+			if !p.XOChip || op.x() != 0 {
+				p.unhandled(op)
+				return
+			}
+			p.setIToLong()
+		case 0x01:
+			if !p.XOChip {
+				p.unhandled(op)
+				return
+			}
+			p.selectPlanes(op.x())
+		case 0x02:
+			if !p.XOChip || op.x() != 0 {
+				p.unhandled(op)
+				return
+			}
+			p.loadAudioPattern()
 		case 0x07:
 			p.setXToDelay(op.x())
 		case 0x0A:
@@ -159,59 +340,246 @@ func (p *Processor) Execute(op Opcode, info *uint8) {
 			p.setIToX(op.x())
 		case 0x29:
 			p.setIToSymbol(op.x())
+		case 0x30:
+			p.setIToBigSymbol(op.x())
 		case 0x33:
 			p.binaryCodedDecimal(op.x())
+		case 0x3A:
+			if !p.XOChip {
+				p.unhandled(op)
+				return
+			}
+			p.setPitchToX(op.x())
 		case 0x55:
 			p.setRegistersToMemory(op.x())
 		case 0x65:
 			p.setMemoryToRegisters(op.x())
+		case 0x75:
+			p.saveFlags(op.x())
+		case 0x85:
+			p.loadFlags(op.x())
 		default:
-			panic("unknown 0xF opcode")
+			p.unhandled(op)
 		}
 	default:
-		panic("unknown opcode")
+		p.unhandled(op)
 	}
 }
 
+// UnhandledOpcode is an unknown opcode executed in permissive mode, or found
+// by Validate.
+type UnhandledOpcode struct {
+	Address uint16
+	Opcode  Opcode
+}
+
+func (u UnhandledOpcode) Error() string {
+	return ErrUnknownOpcode.Error() + " " + u16toh(uint16(u.Opcode), 4) + " at " + u16toh(u.Address, 3)
+}
+
+// Unwrap makes an UnhandledOpcode match ErrUnknownOpcode.
+func (u UnhandledOpcode) Unwrap() error {
+	return ErrUnknownOpcode
+}
+
+// unhandled deals with an unknown opcode, which is a panic with an
+// UnhandledOpcode unless the processor is permissive.
+func (p *Processor) unhandled(op Opcode) {
+	u := UnhandledOpcode{Address: p.here(), Opcode: op}
+	if !p.Permissive {
+		panic(u)
+	}
+
+	if !slices.Contains(p.unhandledOps, u) {
+		p.unhandledOps = append(p.unhandledOps, u)
+		p.warn("unknown opcode ignored", "pc", u.Address, "opcode", op)
+	}
+}
+
+// UnhandledOpcodes lists the distinct unknown opcodes, with their addresses,
+// that were skipped in permissive mode since the last Reset, in the order they
+// were first executed.
+func (p *Processor) UnhandledOpcodes() []UnhandledOpcode {
+	return slices.Clone(p.unhandledOps)
+}
+
+// Reset returns the machine to its power-on state, with the font set loaded
+// and all keys released. Configuration, such as quirks, is kept.
 func (p *Processor) Reset() {
-	*p = Processor{}
+	p.machine = machine{planes: 1, pitch: DefaultPitch}
+	p.ReleaseKeys()
 
-	written := p.Write(FontStartAddress, fontSet)
+	written := p.write(FontStartAddress, fontSet)
 	if int(written) < len(fontSet) {
 		panic("insufficient memory to write font set")
 	}
+
+	written = p.write(BigFontStartAddress, bigFontSet)
+	if int(written) < len(bigFontSet) {
+		panic("insufficient memory to write big font set")
+	}
+}
+
+// Clone returns an independent copy of the processor that can be run on its own,
+// for instance to explore several inputs from the same state in parallel. The
+// memory, registers, stack, display, timers, held keys, RPL user flags,
+// breakpoints, and watched registers are copied. The configuration is copied
+// as well, which means the clone shares the clock set with SetClock, the hooks
+// set with SetFrameHook and SetFlagsHook, the tracer set with SetTracer, and
+// the TraceWriter, Logger, and Rand; give a clone its own before running it on
+// another goroutine. With the default global source, clones do not replay each
+// other's random numbers for CXNN.
+func (p *Processor) Clone() *Processor {
+	c := &Processor{
+		TraceWriter:     p.TraceWriter,
+		Logger:          p.Logger,
+		SpriteGuard:     p.SpriteGuard,
+		Permissive:      p.Permissive,
+		CodeGuard:       p.CodeGuard,
+		StrictAlignment: p.StrictAlignment,
+		Faults:          p.Faults,
+		Costs:           p.Costs,
+		Rand:            p.Rand,
+		DrawMode:        p.DrawMode,
+		PackedDisplay:   p.PackedDisplay,
+		XOChip:          p.XOChip,
+		Palette:         p.Palette,
+		quirks:          p.quirks,
+		clock:           p.clock,
+		clockRate:       p.clockRate,
+		timerRate:       p.timerRate,
+		frameHook:       p.frameHook,
+		flagsHook:       p.flagsHook,
+		tracer:          p.tracer,
+		breakpoints:     maps.Clone(p.breakpoints),
+		watched:         p.watched,
+		rpl:             p.rpl,
+		machine:         p.machine,
+	}
+
+	c.unhandledOps = slices.Clone(p.unhandledOps)
+
+	c.keyMask.Store(p.keyMask.Load())
+	return c
 }
 
 func (p *Processor) Write(loc uint16, data []byte) uint16 {
-	var i uint16
-	for ; loc+i < 0xFFF && int(i) < len(data); i++ {
-		p.memory[loc+i] = data[i]
+	p.guardCode(p.pc, loc, len(data))
+	return p.write(loc, data[:p.intact(loc, len(data))])
+}
+
+// write is Write without the CodeGuard check, for loading the font and ROM.
+func (p *Processor) write(loc uint16, data []byte) uint16 {
+	var i int
+	for last := int(p.lastAddress()); int(loc)+i <= last && i < len(data); i++ {
+		p.memory[int(loc)+i] = data[i]
+	}
+
+	if i < len(data) {
+		p.warn("write clamped to end of memory", "address", loc, "size", len(data), "written", i)
+	}
+	return uint16(i)
+}
+
+// guardCode reports a write of n bytes at addr that comes within CodeGuard
+// bytes of the instruction at pc.
+func (p *Processor) guardCode(pc, addr uint16, n int) {
+	if p.CodeGuard == 0 || n == 0 {
+		return
+	}
+
+	lo := int(pc) - int(p.CodeGuard)
+	hi := int(pc) + 2 + int(p.CodeGuard)
+	if int(addr) < hi && int(addr)+n > lo {
+		p.warn("write near executing code", "pc", pc, "address", addr, "size", n)
 	}
-	return i
 }
 
 func (p *Processor) Read(loc uint16, data []byte) uint16 {
-	var i uint16
-	for ; loc+i < 0xFFF && int(i) < len(data); i++ {
-		data[i] = p.memory[loc+i]
+	var i int
+	for n, last := p.intact(loc, len(data)), int(p.lastAddress()); int(loc)+i <= last && i < n; i++ {
+		data[i] = p.memory[int(loc)+i]
+	}
+	return uint16(i)
+}
+
+func (p *Processor) warn(msg string, args ...any) {
+	if p.Logger != nil {
+		p.Logger.Warn(msg, args...)
 	}
-	return i
 }
 
+func (p *Processor) debug(msg string, args ...any) {
+	if p.Logger != nil {
+		p.Logger.Debug(msg, args...)
+	}
+}
+
+// Display returns the display at one byte per pixel, row by row, at the active
+// resolution reported by Dimensions.
 func (p *Processor) Display() []byte {
-	return p.display[:]
+	return p.pixels()
+}
+
+// CopyDisplay copies the display buffer into dst and returns the number of
+// pixels copied. Unlike Display, the copy is safe to hand to another goroutine.
+func (p *Processor) CopyDisplay(dst []byte) int {
+	return copy(dst, p.pixels())
 }
 
 func (p *Processor) Load(b []byte) {
-	written := p.Write(ProgramStartAddress, b)
-	if int(written) < len(b) {
-		panic("insufficient memory")
+	if err := p.LoadSafe(b); err != nil {
+		panic(err)
 	}
+}
+
+// LoadSafe is like Load, but returns an error wrapping ErrROMTooLarge instead of
+// panicking when b does not fit in memory. The processor is left untouched.
+func (p *Processor) LoadSafe(b []byte) error {
+	if len(b) > p.maxROMSize() {
+		return fmt.Errorf("%w: %d bytes, at most %d fit", ErrROMTooLarge, len(b), p.maxROMSize())
+	}
+	p.write(ProgramStartAddress, b)
 	p.pc = ProgramStartAddress
+	return nil
 }
 
+// SetKey sets whether key is held down. It may be called from any goroutine
+// while the processor runs. Instructions that inspect the whole keypad, such as
+// Fx0A, observe a consistent snapshot: every call to SetKey is either entirely
+// visible to them or not at all.
 func (p *Processor) SetKey(key uint8, value bool) {
-	p.keyState[key&0x0F].Store(value)
+	bit := uint32(1) << (key & 0x0F)
+	if value {
+		p.keyMask.Or(bit)
+	} else {
+		p.keyMask.And(^bit)
+	}
+}
+
+// ReleaseKeys marks every key as released, as if all of them were let go at
+// once. Front-ends call it when they may have missed key-up events, such as
+// when their window loses focus, so no key stays stuck down.
+func (p *Processor) ReleaseKeys() {
+	p.keyMask.Store(0)
+}
+
+// KeyMask returns the state of the whole keypad, with bit k set while key k is
+// held down.
+func (p *Processor) KeyMask() uint16 {
+	return uint16(p.keyMask.Load())
+}
+
+// SetKeyMask sets the state of the whole keypad at once, holding down key k if
+// bit k of mask is set and releasing it otherwise. Like SetKey, it may be
+// called from any goroutine.
+func (p *Processor) SetKeyMask(mask uint16) {
+	p.keyMask.Store(uint32(mask))
+}
+
+// keyDown reports whether key is held down.
+func (p *Processor) keyDown(key uint8) bool {
+	return p.KeyMask()&(1<<(key&0x0F)) != 0
 }
 
 func (p *Processor) Register(v uint8) uint8 {
@@ -231,39 +599,186 @@ func (p *Processor) ProgramCounter() uint16 {
 	return p.pc
 }
 
+// Registers returns V0 through VF at once.
+func (p *Processor) Registers() [RegisterCount]byte {
+	return p.v
+}
+
+// StackFrame returns the return address in frame i of the stack, counting from
+// the bottom, or zero if i is not below StackDepth.
+func (p *Processor) StackFrame(i int) uint16 {
+	if i < 0 || i >= int(p.sp) {
+		return 0
+	}
+	return p.stack[i]
+}
+
+// Delay returns the value of the delay timer.
+func (p *Processor) Delay() uint8 {
+	return p.delay
+}
+
+// Sound returns the value of the sound timer.
+func (p *Processor) Sound() uint8 {
+	return p.sound
+}
+
+// Flags returns the RPL user flags, which FX75 saves registers to and FX85
+// loads them back from. They survive Reset, like the flags of the HP48 outlived
+// the program, but are not part of a Snapshot or save state.
+func (p *Processor) Flags() [FlagCount]byte {
+	return p.rpl
+}
+
+// SetFlags replaces the RPL user flags, for instance with those a previous run
+// saved through the hook set with SetFlagsHook.
+func (p *Processor) SetFlags(flags [FlagCount]byte) {
+	p.rpl = flags
+}
+
+// SetFlagsHook installs hook to be called from Step each time FX75 saves the
+// RPL user flags, so that a host can persist them, since some SUPER-CHIP games
+// keep their high scores there. The hook runs synchronously on the goroutine
+// calling Step. A nil hook removes it.
+func (p *Processor) SetFlagsHook(hook func(flags [FlagCount]byte)) {
+	p.flagsHook = hook
+}
+
 func (p *Processor) OpcodeAt(offset uint16) Opcode {
+	op, err := p.OpcodeAtSafe(offset)
+	if err != nil {
+		panic(err)
+	}
+	return op
+}
+
+// OpcodeAtSafe is like OpcodeAt, but returns an error instead of panicking when
+// offset is too close to the end of memory to hold a whole opcode.
+func (p *Processor) OpcodeAtSafe(offset uint16) (Opcode, error) {
+	var buffer [2]byte
+
+	if err := p.fault(offset, len(buffer)); err != nil {
+		return 0, err
+	}
+
 	read := p.Read(offset, buffer[:])
 	if read < 2 {
-		panic("program runaway")
+		return 0, fmt.Errorf("%w: no opcode at %s", ErrProgramRunaway, u16toh(offset, 3))
 	}
 
 	// opcode is a 16bit value, comprised of two contiguous 8bit values
 	// in memory, starting at the program counter
 	high := uint16(buffer[0]) // high-order bits of opcode
 	low := uint16(buffer[1])  // low-order bits of opcode
-	return Opcode((high << 8) | low)
+	return Opcode((high << 8) | low), nil
+}
+
+// here returns the address of the executing instruction.
+func (p *Processor) here() uint16 {
+	if p.quirks.IncrementAfterExecute {
+		return p.pc
+	}
+	return p.pc - 2
+}
+
+// nextPC returns the address of the instruction after the executing one.
+func (p *Processor) nextPC() uint16 {
+	if p.quirks.IncrementAfterExecute {
+		return p.pc + 2
+	}
+	return p.pc
+}
+
+// setPC transfers control to addr from the executing instruction.
+func (p *Processor) setPC(addr uint16) {
+	p.pc = addr
+	p.jumped = true
 }
 
-func (p *Processor) Step() uint8 {
+// PeekOpcode returns the opcode at the program counter, the next to be
+// executed. At the end of memory it returns zero and false.
+func (p *Processor) PeekOpcode() (Opcode, bool) {
+	return p.PeekOpcodeAt(p.pc)
+}
+
+// PeekOpcodeAt returns the opcode at addr, or zero and false if addr is too
+// close to the end of memory to hold one.
+func (p *Processor) PeekOpcodeAt(addr uint16) (Opcode, bool) {
+	op, err := p.OpcodeAtSafe(addr)
+	return op, err == nil
+}
+
+// DisassembleNext returns the instruction at the program counter, the next to
+// be executed, in the form of Instruction.String. Like the other accessors it
+// has no effect on the machine. At the end of memory it returns the last byte
+// as DB, or the empty string if there is none.
+func (p *Processor) DisassembleNext() string {
+	var buf [4]byte
+	n := p.Read(p.pc, buf[:])
+	if !p.XOChip {
+		n = min(n, 2) // F000 is not a long instruction.
+	}
+
+	listing := Disassemble(buf[:n], p.pc)
+	if len(listing) == 0 {
+		return ""
+	}
+	return listing[0].String()
+}
+
+// Step executes one instruction and updates the timers. The instruction is
+// fetched from the program counter, which is then advanced past it before the
+// instruction executes, so that jumps, calls, and skips are relative to the
+// next instruction. With the IncrementAfterExecute quirk, the program counter
+// instead stays on the instruction while it executes, and is advanced after it
+// unless the instruction transferred control. Both orders run programs the
+// same way, and differ only in the program counter a host observes from within
+// an instruction, such as after a fault.
+//
+// A fault, such as an unknown opcode or a stack overflow, is returned as an
+// *OpcodeError, with the machine left as the faulting instruction left it. The
+// timers are not updated.
+func (p *Processor) Step() (uint8, error) {
 	var info uint8
 
-	opcode := p.OpcodeAt(p.ProgramCounter())
+	addr := p.pc
+	if p.StrictAlignment && addr&1 != 0 {
+		return 0, &OpcodeError{PC: addr, Err: fmt.Errorf("%w: %s", ErrMisalignedPC, u16toh(addr, 3))}
+	}
 
-	p.pc += 2
+	opcode, err := p.OpcodeAtSafe(addr)
+	if err != nil {
+		return 0, &OpcodeError{PC: addr, Err: err}
+	}
 
-	p.Execute(opcode, &info)
+	if p.TraceWriter != nil {
+		_ = p.WriteTrace(p.TraceWriter)
+	}
+	if p.tracer != nil {
+		p.tracer(addr, opcode, p.DisassembleNext())
+	}
 
-	if time.Since(p.lastTimerUpdate) >= TimerRate {
-		if p.sound > 0 {
-			p.sound--
-		}
+	p.jumped = false
+	if !p.quirks.IncrementAfterExecute {
+		p.pc += 2
+	}
 
-		if p.delay > 0 {
-			p.delay--
-		}
-		p.lastTimerUpdate = time.Now()
+	if err := p.execute(opcode, &info, addr); err != nil {
+		return info, &OpcodeError{PC: addr, Opcode: opcode, Err: err}
+	}
+	p.lastCost = p.cost(opcode)
+
+	if info&Redraw != 0 && p.frameHook != nil {
+		w, h := p.Dimensions()
+		p.frameHook(p.pixels(), w, h)
+	}
+
+	if p.quirks.IncrementAfterExecute && !p.jumped {
+		p.pc += 2
 	}
 
+	p.updateTimers()
+
 	if p.sound > 0 {
 		info |= Sound
 	}
@@ -271,5 +786,117 @@ func (p *Processor) Step() uint8 {
 	if p.delay > 0 {
 		info |= Delay
 	}
-	return info
+	return info, nil
+}
+
+// execute runs Execute, converting a fault of the instruction at addr into an
+// error. Faults are errors wrapping the sentinels, which already say where they
+// happened. Anything else gets the address.
+func (p *Processor) execute(op Opcode, info *uint8, addr uint16) (err error) {
+	defer func() {
+		switch r := recover().(type) {
+		case nil:
+		case runtime.Error:
+			err = fmt.Errorf("%w at %s", r, u16toh(addr, 3))
+		case error:
+			err = r
+		default:
+			err = fmt.Errorf("%v at %s", r, u16toh(addr, 3))
+		}
+	}()
+
+	p.Execute(op, info)
+	return nil
+}
+
+// SetClock replaces the source of wall-clock time that drives the delay and
+// sound timers. It is meant for tests that need to control the passage of
+// time. A nil clock restores time.Now.
+func (p *Processor) SetClock(clock func() time.Time) {
+	p.clock = clock
+}
+
+// SetClockRate sets the interval between instructions that ClockRate reports.
+// The processor does not pace itself, so it is the host that executes one
+// instruction per interval, for instance a fast rate for ROMs that crunch
+// numbers or a slow one for debugging. Zero or less restores the package
+// ClockRate. Like the quirks, it survives Reset.
+func (p *Processor) SetClockRate(d time.Duration) {
+	p.clockRate = max(d, 0)
+}
+
+// ClockRate returns the interval between instructions set with SetClockRate,
+// or the package ClockRate if none is set.
+func (p *Processor) ClockRate() time.Duration {
+	if p.clockRate > 0 {
+		return p.clockRate
+	}
+	return ClockRate
+}
+
+// SetTimerRate sets the interval at which Step decrements the delay and sound
+// timers. ROMs are written for TimerRate, so other rates run their timing
+// faster or slower, which is mostly useful for debugging. Zero or less restores
+// the package TimerRate. It takes effect from the next tick, and survives
+// Reset.
+func (p *Processor) SetTimerRate(d time.Duration) {
+	p.timerRate = max(d, 0)
+}
+
+// TimerRate returns the interval between timer ticks set with SetTimerRate, or
+// the package TimerRate if none is set.
+func (p *Processor) TimerRate() time.Duration {
+	if p.timerRate > 0 {
+		return p.timerRate
+	}
+	return TimerRate
+}
+
+func (p *Processor) now() time.Time {
+	if p.clock != nil {
+		return p.clock()
+	}
+	return time.Now()
+}
+
+// SyncTimers discards the time elapsed since the timers were last updated, so
+// that the next Step restarts the timer clock from the current time. A host
+// calls it when resuming after a pause or a breakpoint. Otherwise the whole
+// time spent stopped would be taken off the timers at once.
+func (p *Processor) SyncTimers() {
+	p.lastTimerUpdate = time.Time{}
+}
+
+// TimerDrift reports how many timer ticks were applied late, because more than
+// one tick had elapsed by the time Step was called. A steadily growing value
+// means the host cannot step the processor often enough to keep up with the
+// timer rate.
+func (p *Processor) TimerDrift() uint64 {
+	return p.timerDrift
+}
+
+// updateTimers decrements the delay and sound timers once for every whole
+// interval returned by TimerRate that has elapsed since they were last updated.
+// A host that steps too slowly therefore catches up, rather than running the
+// timers slow, and the fraction of an interval left over carries into the next
+// update.
+func (p *Processor) updateTimers() {
+	now := p.now()
+	if p.lastTimerUpdate.IsZero() {
+		p.lastTimerUpdate = now
+		return
+	}
+
+	rate := p.TimerRate()
+	ticks := now.Sub(p.lastTimerUpdate) / rate
+	if ticks <= 0 {
+		return
+	}
+
+	p.drawn = false
+	p.sound -= min(p.sound, uint8(min(ticks, 255)))
+	p.delay -= min(p.delay, uint8(min(ticks, 255)))
+
+	p.lastTimerUpdate = p.lastTimerUpdate.Add(ticks * rate)
+	p.timerDrift += uint64(ticks - 1)
 }
@@ -0,0 +1,330 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock for SetClock that only moves when told to.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestTimerCatchUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		advances  []time.Duration // Clock advance before each step.
+		wantDelay uint8
+		wantDrift uint64
+	}{
+		{"one tick per step", []time.Duration{TimerRate, TimerRate, TimerRate}, 7, 0},
+		{"three ticks at once", []time.Duration{3 * TimerRate}, 7, 2},
+		{"fraction carries", []time.Duration{TimerRate * 3 / 2, TimerRate / 2}, 8, 0},
+		{"less than a tick", []time.Duration{TimerRate / 2, TimerRate / 4}, 10, 0},
+		{"past zero", []time.Duration{20 * TimerRate}, 0, 19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+
+			var p Processor
+			p.Reset()
+			p.SetClock(clock.Now)
+
+			// LD V0, 10; LD DT, V0; JP 0x204
+			p.Load([]byte{0x60, 10, 0xF0, 0x15, 0x12, 0x04})
+			step(t, &p)
+			step(t, &p) // The first update starts the timer clock.
+
+			for _, d := range tt.advances {
+				clock.Advance(d)
+				step(t, &p)
+			}
+
+			if got := p.Delay(); got != tt.wantDelay {
+				t.Errorf("Delay() = %d, want %d", got, tt.wantDelay)
+			}
+			if got := p.TimerDrift(); got != tt.wantDrift {
+				t.Errorf("TimerDrift() = %d, want %d", got, tt.wantDrift)
+			}
+		})
+	}
+}
+
+func TestPauseFreezesTimers(t *testing.T) {
+	tests := []struct {
+		name       string
+		sync       bool
+		wantResume uint8 // Delay after the first step after the pause.
+		wantTick   uint8 // Delay one tick later.
+	}{
+		{"synced", true, 100, 99},
+		{"not synced", false, 40, 39},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+
+			var p Processor
+			p.Reset()
+			p.SetClock(clock.Now)
+
+			// LD V0, 100; LD DT, V0; JP 0x204, stopping at the jump.
+			p.Load([]byte{0x60, 100, 0xF0, 0x15, 0x12, 0x04})
+			p.AddBreakpoint(0x204)
+			if _, err := p.RunUntilBreak(10); err != nil {
+				t.Fatal(err)
+			}
+
+			// A second at the breakpoint.
+			clock.Advance(time.Second)
+			if tt.sync {
+				p.SyncTimers()
+			}
+
+			step(t, &p)
+			if got := p.Delay(); got != tt.wantResume {
+				t.Errorf("Delay() after resuming = %d, want %d", got, tt.wantResume)
+			}
+
+			clock.Advance(TimerRate)
+			step(t, &p)
+			if got := p.Delay(); got != tt.wantTick {
+				t.Errorf("Delay() a tick later = %d, want %d", got, tt.wantTick)
+			}
+		})
+	}
+}
+
+func TestLoadSafe(t *testing.T) {
+	tests := []struct {
+		name    string
+		xochip  bool
+		size    int
+		wantErr bool
+	}{
+		{"fits", false, MaxROMSize, false},
+		{"too large", false, MaxROMSize + 1, true},
+		{"xo-chip fits", true, XOChipMaxROMSize, false},
+		{"xo-chip too large", true, XOChipMaxROMSize + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.XOChip = tt.xochip
+			p.Reset()
+			p.Load([]byte{0x60, 0x07}) // LD V0, 7
+			step(t, &p)
+
+			rom := bytes.Repeat([]byte{0xAB}, tt.size)
+			err := p.LoadSafe(rom)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := p.ProgramCounter(); got != ProgramStartAddress {
+					t.Errorf("PC = %s, want %s", u16toh(got, 3), u16toh(ProgramStartAddress, 3))
+				}
+				if got := p.memory[int(ProgramStartAddress)+tt.size-1]; got != 0xAB {
+					t.Errorf("last byte = %02X, want AB", got)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrROMTooLarge) {
+				t.Fatalf("error %v, want ErrROMTooLarge", err)
+			}
+			// The machine is as it was before the failed load.
+			if got := p.ProgramCounter(); got != 0x202 {
+				t.Errorf("PC = %s, want 202", u16toh(got, 3))
+			}
+			if got := p.memory[ProgramStartAddress]; got != 0x60 {
+				t.Errorf("first byte = %02X, want 60", got)
+			}
+			if got := p.memory[ProgramStartAddress+2]; got != 0 {
+				t.Errorf("byte after the program = %02X, want 0", got)
+			}
+		})
+	}
+}
+
+func TestIncrementOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		rom   []byte
+		steps int
+		// The PC after the steps, with the PC incremented before and after
+		// the instruction executes.
+		before, after uint16
+		stack         uint16 // The return address left on the stack, if any.
+	}{
+		{"jump", []byte{0x13, 0x00}, 1, 0x300, 0x300, 0},
+		{"jump to self", []byte{0x12, 0x00}, 1, 0x200, 0x200, 0},
+		{"jump with offset", []byte{0x60, 0x04, 0xB3, 0x00}, 2, 0x304, 0x304, 0},
+		{"call", []byte{0x23, 0x00}, 1, 0x300, 0x300, 0x202},
+		{"call and return", []byte{0x22, 0x04, 0x00, 0x00, 0x00, 0xEE}, 2, 0x202, 0x202, 0},
+		{"skip taken", []byte{0x30, 0x00}, 1, 0x204, 0x204, 0},
+		{"skip not taken", []byte{0x40, 0x00}, 1, 0x202, 0x202, 0},
+		{"skip if key up", []byte{0xE0, 0xA1}, 1, 0x204, 0x204, 0},
+		{"wait for key", []byte{0xF0, 0x0A}, 1, 0x200, 0x200, 0},
+		// Only a fault shows the difference: the PC is left on the next
+		// instruction, or on the faulting one.
+		{"fault", []byte{0x00, 0xEE}, 1, 0x202, 0x200, 0},
+	}
+
+	for _, tt := range tests {
+		for _, afterExecute := range []bool{false, true} {
+			name := tt.name + "/before"
+			want := tt.before
+			if afterExecute {
+				name, want = tt.name+"/after", tt.after
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var p Processor
+				p.Reset()
+				p.SetQuirks(Quirks{IncrementAfterExecute: afterExecute})
+				p.Load(tt.rom)
+				for range tt.steps {
+					_, _ = p.Step()
+				}
+
+				if got := p.ProgramCounter(); got != want {
+					t.Errorf("PC = %s, want %s", u16toh(got, 3), u16toh(want, 3))
+				}
+				if got := p.StackFrame(0); got != tt.stack {
+					t.Errorf("return address = %s, want %s", u16toh(got, 3), u16toh(tt.stack, 3))
+				}
+			})
+		}
+	}
+}
+
+func TestStrictAlignment(t *testing.T) {
+	// From 203, the bytes 61 07 straddle two words and read as LD V1, 7.
+	tail := []byte{0x00, 0x61, 0x07, 0x00}
+
+	tests := []struct {
+		name   string
+		jump   []byte
+		strict bool
+		target uint16
+	}{
+		{"jump", []byte{0x12, 0x03}, true, 0x203},
+		{"call", []byte{0x22, 0x03}, true, 0x203},
+		{"jump with offset", []byte{0xB2, 0x02}, true, 0x203},
+		{"jump lenient", []byte{0x12, 0x03}, false, 0x203},
+		{"jump with offset lenient", []byte{0xB2, 0x02}, false, 0x203},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.StrictAlignment = tt.strict
+			p.WithRegister(0, 1).Load(slices.Concat(tt.jump, tail))
+
+			step(t, &p) // The jump itself is even.
+			if got := p.ProgramCounter(); got != tt.target {
+				t.Fatalf("PC after jump = %s, want %s", u16toh(got, 3), u16toh(tt.target, 3))
+			}
+
+			_, err := p.Step()
+			if !tt.strict {
+				if err != nil {
+					t.Fatalf("lenient step at odd PC: %v", err)
+				}
+				if got := p.Register(1); got != 7 {
+					t.Errorf("V1 = %d, want 7 from the straddling opcode", got)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrMisalignedPC) {
+				t.Fatalf("error %v, want ErrMisalignedPC", err)
+			}
+			var oe *OpcodeError
+			if !errors.As(err, &oe) || oe.PC != tt.target {
+				t.Errorf("error %v does not report PC %s", err, u16toh(tt.target, 3))
+			}
+			if got := p.ProgramCounter(); got != tt.target {
+				t.Errorf("PC after fault = %s, want %s", u16toh(got, 3), u16toh(tt.target, 3))
+			}
+			if got := p.Register(1); got != 0 {
+				t.Errorf("V1 = %d, want the straddling opcode not executed", got)
+			}
+		})
+	}
+}
+
+// BenchmarkDispatch steps a clear, draw, and jump loop, which dominates most
+// game loops, through the switch of Execute alone, and through a fast path that
+// checks for the three opcodes ahead of it.
+func BenchmarkDispatch(b *testing.B) {
+	fast := func(p *Processor, op Opcode, info *uint8) {
+		switch {
+		case op == 0x00E0:
+			p.clearScreen(info)
+		case op.kind() == 0xD:
+			p.drawSprite(op.x(), op.y(), op.n(), info)
+		case op.kind() == 0x1:
+			p.jumpToLocation(op.nnn())
+		default:
+			p.Execute(op, info)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		execute func(p *Processor, op Opcode, info *uint8)
+	}{
+		{"switch", (*Processor).Execute},
+		{"fast path", fast},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			var p Processor
+			p.Reset()
+			// CLS; DRW V0, V1, 5; JP 200
+			p.Load([]byte{0x00, 0xE0, 0xD0, 0x15, 0x12, 0x00})
+
+			for b.Loop() {
+				var info uint8
+				op := p.OpcodeAt(p.pc)
+				p.pc += 2
+				tt.execute(&p, op, &info)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// RunUntil steps the processor until the program counter reaches addr or
+// maxCycles instructions have been executed, whichever comes first, stopping
+// early at breakpoints and watched registers as RunUntilBreak does. At least
+// one instruction is always executed, so running until the current address
+// stops the next time execution returns to it. A maxCycles of zero or less
+// means there is no limit.
+//
+// The returned info is the union of the info bits of every step taken. The
+// error is nil when addr was reached, ErrBreakpoint when a breakpoint or watch
+// stopped the run first, ErrCycleBudget when the budget ran out first, and the
+// *OpcodeError of the step when an instruction faulted.
+func (p *Processor) RunUntil(addr uint16, maxCycles int) (uint8, error) {
+	reached := false
+	info, err := p.run(maxCycles, func() bool {
+		reached = p.pc == addr
+		return reached
+	})
+	if err == nil && !reached {
+		err = ErrBreakpoint
+	}
+	return info, err
+}
+
+// AddBreakpoint sets a breakpoint at addr, which makes RunUntilBreak stop before
+// executing the instruction there. Setting one twice is harmless. Breakpoints
+// survive Reset, so that a ROM can be restarted under the same ones.
+func (p *Processor) AddBreakpoint(addr uint16) {
+	if p.breakpoints == nil {
+		p.breakpoints = make(map[uint16]struct{})
+	}
+	p.breakpoints[addr] = struct{}{}
+}
+
+// RemoveBreakpoint removes the breakpoint at addr, if there is one.
+func (p *Processor) RemoveBreakpoint(addr uint16) {
+	delete(p.breakpoints, addr)
+}
+
+// Breakpoints returns the addresses of the breakpoints, in order.
+func (p *Processor) Breakpoints() []uint16 {
+	return slices.Sorted(maps.Keys(p.breakpoints))
+}
+
+// WatchRegister makes RunUntilBreak stop after any instruction that changes
+// register Vx, which finds what writes a register faster than stepping. Like
+// breakpoints, watches survive Reset.
+func (p *Processor) WatchRegister(x uint8) {
+	p.watched |= 1 << (x & 0xF)
+}
+
+// UnwatchRegister removes the watch on register Vx, if there is one.
+func (p *Processor) UnwatchRegister(x uint8) {
+	p.watched &^= 1 << (x & 0xF)
+}
+
+// RunUntilBreak steps the processor until the program counter reaches a
+// breakpoint, a watched register changes, or maxCycles instructions have been
+// executed, whichever comes first. The instruction at a breakpoint is not
+// executed, so the state can be inspected before it, but at least one
+// instruction always is, so calling RunUntilBreak again resumes from the
+// breakpoint. A maxCycles of zero or less means there is no limit.
+//
+// The info and error are as from RunUntil: the error is nil when the run
+// stopped at a breakpoint or watch, ErrCycleBudget when the budget ran out
+// first, and the *OpcodeError of the step when an instruction faulted.
+func (p *Processor) RunUntilBreak(maxCycles int) (uint8, error) {
+	return p.run(maxCycles, func() bool { return false })
+}
+
+// run steps the processor until stop reports true after a step, the program
+// counter reaches a breakpoint, a watched register changes, or maxCycles
+// instructions have been executed. The error is nil if it stopped for any of
+// the first three, and otherwise as for RunUntilBreak.
+func (p *Processor) run(maxCycles int, stop func() bool) (uint8, error) {
+	var info uint8
+
+	for cycles := 0; maxCycles <= 0 || cycles < maxCycles; cycles++ {
+		before := p.v
+		i, err := p.Step()
+		info |= i
+		if err != nil {
+			return info, err
+		}
+
+		if stop() {
+			return info, nil
+		}
+		if _, ok := p.breakpoints[p.pc]; ok || p.watchTriggered(before) {
+			return info, nil
+		}
+	}
+	return info, ErrCycleBudget
+}
+
+// watchTriggered reports whether a watched register differs from before.
+func (p *Processor) watchTriggered(before [RegisterCount]byte) bool {
+	for x := range p.v {
+		if p.watched&(1<<x) != 0 && p.v[x] != before[x] {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRegister sets register Vi to value. Together with the other With methods
+// it allows a processor to be put into a precise state, for instance to test a
+// single instruction without running a ROM:
+//
+//	var p chip8.Processor
+//	p.Reset()
+//	p.WithPC(0x300).WithRegister(0x0, 0xFF).WithMemory(0x300, []byte{0x70, 0x01})
+//	info, err := p.Step()
+//
+// Each method returns p so that calls can be chained.
+func (p *Processor) WithRegister(i uint8, value uint8) *Processor {
+	p.v[i&0xF] = value
+	return p
+}
+
+// WithIndex sets the index register.
+func (p *Processor) WithIndex(i uint16) *Processor {
+	p.i = i
+	return p
+}
+
+// WithPC sets the program counter.
+func (p *Processor) WithPC(pc uint16) *Processor {
+	p.pc = pc
+	return p
+}
+
+// WithMemory writes data to memory starting at addr. It panics if data does not
+// fit, since a truncated fixture would silently change what is being tested.
+func (p *Processor) WithMemory(addr uint16, data []byte) *Processor {
+	written := p.Write(addr, data)
+	if int(written) < len(data) {
+		panic(fmt.Errorf("%w: %d bytes at %s", ErrMemoryRange, len(data), u16toh(addr, 3)))
+	}
+	return p
+}
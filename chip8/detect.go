@@ -0,0 +1,63 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "emul8/byteconv"
+
+// Detect guesses which platform rom was written for, by looking for opcodes
+// that only exist in the SUPER-CHIP and XO-CHIP extensions. Like Validate, it
+// sweeps every aligned word, so it is only a guess: data can look like an
+// extension opcode, and a ROM that happens to use none of them is reported as
+// ProfileVIP whatever it was written for.
+func Detect(rom []byte) Profile {
+	profile := ProfileVIP
+	for off := 0; off+1 < len(rom); off += 2 {
+		switch op := Opcode(byteconv.Btou16(rom[off:])); {
+		case isXOCHIP(op):
+			return ProfileXOCHIP
+		case isSCHIP(op):
+			profile = ProfileSCHIP
+		}
+	}
+	return profile
+}
+
+func isSCHIP(op Opcode) bool {
+	switch {
+	case uint16(op) >= 0x00FB && uint16(op) <= 0x00FF: // scroll, exit, resolution
+		return true
+	case uint16(op)&0xFFF0 == 0x00C0: // scroll down
+		return true
+	case op.kind() == 0xF && (op.nn() == 0x30 || op.nn() == 0x75 || op.nn() == 0x85):
+		return true
+	}
+	return false
+}
+
+func isXOCHIP(op Opcode) bool {
+	switch {
+	case uint16(op)&0xFFF0 == 0x00D0: // scroll up
+		return true
+	case op.kind() == 0x5 && (op.n() == 0x2 || op.n() == 0x3): // register ranges
+		return true
+	case uint16(op) == 0xF000 || uint16(op) == 0xF002: // long I, audio pattern
+		return true
+	case op.kind() == 0xF && (op.nn() == 0x01 || op.nn() == 0x3A): // plane, pitch
+		return true
+	}
+	return false
+}
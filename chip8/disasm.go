@@ -0,0 +1,284 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"emul8/byteconv"
+	"strings"
+)
+
+const (
+	byteData    uint8 = iota // byte not reached as code
+	byteCode                 // first byte of an instruction
+	byteOperand              // second byte of an instruction
+)
+
+// DisassembleROM produces a labeled assembly listing of rom, as loaded at
+// ProgramStartAddress. Rather than decoding every word, it follows the control
+// flow from the entry point: jumps, calls, returns, and skips decide which
+// bytes are reachable code. Everything else is emitted as DB data, eight bytes
+// to a line, each in the 0xNN form of the DB operands of Disassemble. Targets
+// of jumps, calls, and LD I instructions that fall within the ROM are given
+// labels of the form L2A0, and references to them use the label.
+//
+// Computed jumps (BNNN) cannot be followed statically, so code that is only
+// reachable through one is listed as data. XO-CHIP's F000 NNNN is listed as
+// one instruction, LD I, NNNN.
+func DisassembleROM(rom []byte) string {
+	kinds := make([]uint8, len(rom))
+	labels := make(map[uint16]bool)
+
+	inROM := func(addr uint16) bool {
+		return addr >= ProgramStartAddress && int(addr-ProgramStartAddress) < len(rom)
+	}
+
+	// long reports whether the instruction at addr is F000 NNNN, which is
+	// four bytes long.
+	long := func(addr uint16) bool {
+		off := int(addr - ProgramStartAddress)
+		return inROM(addr) && off+3 < len(rom) && rom[off] == 0xF0 && rom[off+1] == 0x00
+	}
+
+	work := []uint16{ProgramStartAddress}
+	for len(work) > 0 {
+		addr := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		if !inROM(addr) || !inROM(addr+1) {
+			continue
+		}
+
+		off := addr - ProgramStartAddress
+		if kinds[off] != byteData || kinds[off+1] != byteData {
+			// Already visited, or overlaps an instruction decoded from another path.
+			continue
+		}
+
+		op := Opcode(uint16(rom[off])<<8 | uint16(rom[off+1]))
+		if _, ok := op.Mnemonic(); !ok {
+			continue
+		}
+
+		if long(addr) {
+			if kinds[off+2] != byteData || kinds[off+3] != byteData {
+				continue
+			}
+			kinds[off], kinds[off+1], kinds[off+2], kinds[off+3] = byteCode, byteOperand, byteOperand, byteOperand
+			work = append(work, addr+4)
+			continue
+		}
+		kinds[off], kinds[off+1] = byteCode, byteOperand
+
+		next := addr + 2
+		skip := next + 2
+		if long(next) {
+			skip = next + 4
+		}
+		switch op.kind() {
+		case 0x0:
+			if uint16(op) != 0x00EE {
+				work = append(work, next)
+			}
+		case 0x1:
+			labels[op.nnn()] = true
+			work = append(work, op.nnn())
+		case 0x2:
+			labels[op.nnn()] = true
+			work = append(work, next, op.nnn())
+		case 0x3, 0x4, 0x5, 0x9, 0xE:
+			work = append(work, next, skip)
+		case 0xA:
+			labels[op.nnn()] = true
+			work = append(work, next)
+		case 0xB:
+			labels[op.nnn()] = true
+		default:
+			work = append(work, next)
+		}
+	}
+
+	// A label is only usable if it can be emitted on its own line, which is not
+	// the case for addresses outside the ROM or inside an instruction.
+	for addr := range labels {
+		if !inROM(addr) || kinds[addr-ProgramStartAddress] == byteOperand {
+			delete(labels, addr)
+		}
+	}
+
+	label := func(addr uint16) string {
+		return "L" + u16toh(addr, 3)
+	}
+
+	ref := func(addr uint16) string {
+		if labels[addr] {
+			return label(addr)
+		}
+		return u16toh(addr, 3)
+	}
+
+	var sb strings.Builder
+	var data []string
+
+	flush := func() {
+		if len(data) > 0 {
+			sb.WriteString("    DB " + strings.Join(data, ", ") + "\n")
+			data = data[:0]
+		}
+	}
+
+	for off := 0; off < len(rom); off++ {
+		addr := ProgramStartAddress + uint16(off)
+
+		if labels[addr] {
+			flush()
+			sb.WriteString(label(addr) + ":\n")
+		}
+
+		if kinds[off] != byteCode {
+			data = append(data, "0x"+u8toh(rom[off], 2))
+			if len(data) == 8 {
+				flush()
+			}
+			continue
+		}
+		flush()
+
+		op := Opcode(uint16(rom[off])<<8 | uint16(rom[off+1]))
+
+		if long(addr) {
+			sb.WriteString("    LD I, " + u16toh(byteconv.Btou16(rom[off+2:]), 4) + "\n")
+			off += 3
+			continue
+		}
+
+		var str string
+		switch op.kind() {
+		case 0x1:
+			str = "JP " + ref(op.nnn())
+		case 0x2:
+			str = "CALL " + ref(op.nnn())
+		case 0xA:
+			str = "LD I, " + ref(op.nnn())
+		case 0xB:
+			str = "JP V0, " + ref(op.nnn())
+		default:
+			str, _ = op.Mnemonic()
+		}
+		sb.WriteString("    " + str + "\n")
+		off++
+	}
+	flush()
+
+	return sb.String()
+}
+
+// Instruction is one decoded word of a listing produced by Disassemble.
+type Instruction struct {
+	Address uint16
+	Opcode  Opcode
+
+	// Mnemonic and Operands are the assembly form of Opcode, as returned by
+	// Opcode.Mnemonic, split apart: for D015, DRW and V0, V1, 5. Addresses and
+	// bytes are given in the 0x form, as for A2A0, LD and I, 0x2A0, while the
+	// nibble counts of DRW and the scrolls stay bare. A word that is not a
+	// known instruction is DB, with the word as its only operand in the form
+	// of Opcode.String, such as 0xE0A0.
+	Mnemonic string
+	Operands []string
+}
+
+// String returns the instruction in assembly form, such as "DRW VA, V1, 5".
+func (in Instruction) String() string {
+	if len(in.Operands) == 0 {
+		return in.Mnemonic
+	}
+	return in.Mnemonic + " " + strings.Join(in.Operands, ", ")
+}
+
+// Disassemble decodes every aligned word of rom, as loaded at origin, into an
+// instruction, without executing anything. Unlike DisassembleROM it does not
+// follow the control flow, so it is a heuristic linear sweep: sprites and other
+// data embedded in the ROM are decoded as instructions too, and those that are
+// not valid opcodes are emitted as DB. A trailing odd byte is emitted as DB as
+// well, with the byte as both its operand and the high byte of its Opcode.
+// XO-CHIP's F000 NNNN is decoded as one instruction four bytes long, LD I with
+// 0xNNNN as its operand.
+func Disassemble(rom []byte, origin uint16) []Instruction {
+	listing := make([]Instruction, 0, (len(rom)+1)/2)
+	for off := 0; off < len(rom); off += 2 {
+		in := Instruction{Address: origin + uint16(off)}
+
+		if off+1 == len(rom) {
+			in.Opcode = Opcode(uint16(rom[off]) << 8)
+			in.Mnemonic, in.Operands = "DB", []string{"0x" + u8toh(rom[off], 2)}
+			listing = append(listing, in)
+			break
+		}
+
+		in.Opcode = Opcode(byteconv.Btou16(rom[off:]))
+		if in.Opcode == 0xF000 && off+3 < len(rom) {
+			in.Mnemonic = "LD"
+			in.Operands = []string{"I", "0x" + u16toh(byteconv.Btou16(rom[off+2:]), 4)}
+			listing = append(listing, in)
+			off += 2
+			continue
+		}
+
+		str, ok := in.Opcode.Mnemonic()
+		if !ok {
+			str = "DB 0x" + u16toh(uint16(in.Opcode), 4)
+		}
+
+		mnemonic, operands, _ := strings.Cut(str, " ")
+		in.Mnemonic = mnemonic
+		if operands != "" {
+			in.Operands = strings.Split(operands, ", ")
+			for i, operand := range in.Operands {
+				in.Operands[i] = hexOperand(operand)
+			}
+		}
+		listing = append(listing, in)
+	}
+	return listing
+}
+
+// hexOperand returns an operand of Opcode.Mnemonic in the 0x form if it is an
+// address or a byte, which are at least two hex digits. Registers, such as VA
+// and DT, and single-digit nibbles are returned as they are.
+func hexOperand(operand string) string {
+	if len(operand) < 2 || strings.Trim(operand, "0123456789ABCDEF") != "" {
+		return operand
+	}
+	return "0x" + operand
+}
+
+// Validate decodes every aligned word of rom, as loaded at ProgramStartAddress,
+// and returns an UnhandledOpcode error for each one that is not a known
+// instruction, without executing anything. It is a heuristic linear sweep:
+// sprites and other data embedded in the ROM are decoded as well, so an error
+// does not necessarily mean the ROM will ever execute that word. A trailing odd
+// byte is ignored.
+func Validate(rom []byte) []error {
+	var errs []error
+	for off := 0; off+1 < len(rom); off += 2 {
+		op := Opcode(byteconv.Btou16(rom[off:]))
+		if _, ok := op.Mnemonic(); !ok {
+			errs = append(errs, UnhandledOpcode{Address: ProgramStartAddress + uint16(off), Opcode: op})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want string
+	}{
+		{"address", []byte{0xA2, 0xA0}, "LD I, 0x2A0"},
+		{"jump", []byte{0x12, 0x00}, "JP 0x200"},
+		{"byte", []byte{0x61, 0x05}, "LD V1, 0x05"},
+		{"nibble", []byte{0xD0, 0x15}, "DRW V0, V1, 5"},
+		{"scroll", []byte{0x00, 0xC3}, "SCD 3"},
+		{"registers", []byte{0x51, 0x21}, "SE V1, V2"},
+		{"timer", []byte{0xFA, 0x15}, "LD DT, VA"},
+		{"long load", []byte{0xF0, 0x00, 0x12, 0x34}, "LD I, 0x1234"},
+		{"invalid", []byte{0xE0, 0xA0}, "DB 0xE0A0"},
+		{"odd byte", []byte{0xAB}, "DB 0xAB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := Disassemble(tt.rom, ProgramStartAddress)
+			if len(listing) != 1 {
+				t.Fatalf("%d instructions, want 1", len(listing))
+			}
+			if got := listing[0].String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisassembleROMData(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want string
+	}{
+		{
+			name: "sprite",
+			// LD I, 206; DRW V0, V1, 5; JP 202; and a sprite.
+			rom:  []byte{0xA2, 0x06, 0xD0, 0x15, 0x12, 0x02, 0xF0, 0x90, 0x51, 0x21},
+			want: "    LD I, L206\nL202:\n    DRW V0, V1, 5\n    JP L202\nL206:\n    DB 0xF0, 0x90, 0x51, 0x21\n",
+		},
+		{
+			name: "eight to a line",
+			rom:  append([]byte{0x12, 0x00}, bytes.Repeat([]byte{0xAB}, 9)...),
+			want: "L200:\n    JP L200\n    DB 0xAB, 0xAB, 0xAB, 0xAB, 0xAB, 0xAB, 0xAB, 0xAB\n    DB 0xAB\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DisassembleROM(tt.rom); got != tt.want {
+				t.Errorf("DisassembleROM() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+// The display is kept either at one byte per pixel in machine.display, or at
+// one bit per pixel and plane in machine.packed when PackedDisplay is set,
+// with the leftmost pixel of each byte in its most significant bit. A pixel
+// value holds one bit per plane, so it is 0 or 1 unless an XO-CHIP ROM draws to
+// the second plane. Only the first width times height pixels are in use, row
+// by row at the active resolution, and the rest may hold stale pixels of the
+// other resolution. Everything outside this file goes through the accessors
+// below, and need not know which.
+
+// Planes is the number of bitplanes of the XO-CHIP display.
+const Planes = 2
+
+// allPlanes selects every plane.
+const allPlanes byte = 1<<Planes - 1
+
+// width returns the width of the display at the active resolution.
+func (p *Processor) width() int {
+	if p.hires {
+		return HiResWidth
+	}
+	return Width
+}
+
+// height returns the height of the display at the active resolution.
+func (p *Processor) height() int {
+	if p.hires {
+		return HiResHeight
+	}
+	return Height
+}
+
+// area returns the number of pixels at the active resolution.
+func (p *Processor) area() int {
+	if p.hires {
+		return HiResArea
+	}
+	return Area
+}
+
+// activePlanes returns the planes that drawing, clearing, and scrolling apply
+// to: those selected with FN01 in XO-CHIP mode, and otherwise the first.
+func (p *Processor) activePlanes() byte {
+	if p.XOChip {
+		return p.planes & allPlanes
+	}
+	return 1
+}
+
+func (p *Processor) pixel(index int) byte {
+	if p.PackedDisplay {
+		var val byte
+		for plane := range Planes {
+			val |= (p.packed[plane][index/8] >> (7 - index%8)) & 1 << plane
+		}
+		return val
+	}
+	return p.display[index]
+}
+
+// flipPixel flips the pixel in the planes set in mask.
+func (p *Processor) flipPixel(index int, mask byte) {
+	if p.PackedDisplay {
+		for plane := range Planes {
+			if mask&(1<<plane) != 0 {
+				p.packed[plane][index/8] ^= 0x80 >> (index % 8)
+			}
+		}
+		return
+	}
+	p.display[index] ^= mask
+}
+
+func (p *Processor) clearDisplay() {
+	p.clearPlanes(allPlanes)
+}
+
+// clearPlanes clears the planes set in mask, leaving the others as they are.
+func (p *Processor) clearPlanes(mask byte) {
+	if p.PackedDisplay {
+		for plane := range Planes {
+			if mask&(1<<plane) != 0 {
+				clear(p.packed[plane][:p.area()/8])
+			}
+		}
+		return
+	}
+
+	if mask&allPlanes == allPlanes {
+		clear(p.display[:p.area()])
+		return
+	}
+	for i := range p.display[:p.area()] {
+		p.display[i] &^= mask
+	}
+}
+
+// pixels returns the display at one byte per pixel. When the display is packed,
+// it is first unpacked into machine.display, which otherwise goes unused. The
+// slice aliases the processor's memory.
+func (p *Processor) pixels() []byte {
+	area := p.area()
+	if p.PackedDisplay {
+		for i := range area {
+			p.display[i] = p.pixel(i)
+		}
+	}
+	return p.display[:area]
+}
+
+// setPixels replaces the display with src, given at one byte per pixel at the
+// active resolution.
+func (p *Processor) setPixels(src []byte) {
+	if !p.PackedDisplay {
+		copy(p.display[:], src)
+		return
+	}
+
+	for plane := range Planes {
+		clear(p.packed[plane][:])
+	}
+	for i, val := range src {
+		for plane := range Planes {
+			if val&(1<<plane) != 0 {
+				p.packed[plane][i/8] |= 0x80 >> (i % 8)
+			}
+		}
+	}
+}
+
+// setResolution switches between the low and high resolution modes. The
+// display is cleared, or with the ResolutionKeepsDisplay quirk, scaled to the
+// new resolution: each pixel becomes two by two pixels in high resolution,
+// and each two by two block becomes one pixel, lit if any of it was, in low
+// resolution.
+func (p *Processor) setResolution(hires bool) {
+	if hires == p.hires {
+		if !p.quirks.ResolutionKeepsDisplay {
+			p.clearDisplay()
+		}
+		return
+	}
+
+	if !p.quirks.ResolutionKeepsDisplay {
+		p.hires = hires
+		p.clearDisplay()
+		return
+	}
+
+	var dst [HiResArea]byte
+	src := p.pixels()
+	if hires {
+		for i, val := range src {
+			x, y := 2*(i%Width), 2*(i/Width)
+			dst[y*HiResWidth+x], dst[y*HiResWidth+x+1] = val, val
+			dst[(y+1)*HiResWidth+x], dst[(y+1)*HiResWidth+x+1] = val, val
+		}
+	} else {
+		for i, val := range src {
+			x, y := i%HiResWidth/2, i/HiResWidth/2
+			dst[y*Width+x] |= val
+		}
+	}
+
+	p.hires = hires
+	p.setPixels(dst[:p.area()])
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "testing"
+
+func TestClearPlanes(t *testing.T) {
+	tests := []struct {
+		name string
+		mask uint8 // Selected by FN01 before the clear.
+		want uint8 // The pixel after the clear, lit in both planes before.
+	}{
+		{"no planes", 0, 3},
+		{"plane 1", 1, 2},
+		{"plane 2", 2, 1},
+		{"both planes", 3, 0},
+	}
+
+	for _, tt := range tests {
+		for _, packed := range []bool{false, true} {
+			name := tt.name + "/bytes"
+			if packed {
+				name = tt.name + "/packed"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				var p Processor
+				p.XOChip = true
+				p.PackedDisplay = packed
+				p.Reset()
+
+				// PLANE 3; LD I, 300; DRW V0, V1, 1; PLANE mask; CLS
+				p.WithMemory(0x300, []byte{0x80, 0x80}).
+					Load([]byte{0xF3, 0x01, 0xA3, 0x00, 0xD0, 0x11, 0xF0 | tt.mask, 0x01, 0x00, 0xE0})
+				for range 3 {
+					step(t, &p)
+				}
+				if got := p.Display()[0]; got != 3 {
+					t.Fatalf("pixel before the clear = %d, want 3", got)
+				}
+
+				step(t, &p)
+				step(t, &p)
+				if got := p.Display()[0]; got != tt.want {
+					t.Errorf("pixel after the clear = %d, want %d", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestResolutionKeepsDisplay(t *testing.T) {
+	tests := []struct {
+		name  string
+		keep  bool
+		hires bool // The starting resolution.
+		rom   []byte
+		lit   map[point]byte
+		want  map[point]byte
+	}{
+		{
+			name: "scale to high res",
+			keep: true,
+			rom:  []byte{0x00, 0xFF},
+			lit:  map[point]byte{{1, 1}: 1},
+			want: map[point]byte{{2, 2}: 1, {3, 2}: 1, {2, 3}: 1, {3, 3}: 1},
+		},
+		{
+			name:  "scale to low res",
+			keep:  true,
+			hires: true,
+			rom:   []byte{0x00, 0xFE},
+			lit:   map[point]byte{{3, 2}: 1},
+			want:  map[point]byte{{1, 1}: 1},
+		},
+		{
+			name: "scale there and back",
+			keep: true,
+			rom:  []byte{0x00, 0xFF, 0x00, 0xFE},
+			lit:  map[point]byte{{1, 1}: 1, {63, 31}: 1},
+			want: map[point]byte{{1, 1}: 1, {63, 31}: 1},
+		},
+		{
+			name:  "keep in the same mode",
+			keep:  true,
+			hires: true,
+			rom:   []byte{0x00, 0xFF},
+			lit:   map[point]byte{{3, 2}: 1},
+			want:  map[point]byte{{3, 2}: 1},
+		},
+		{
+			name: "clear to high res",
+			rom:  []byte{0x00, 0xFF},
+			lit:  map[point]byte{{1, 1}: 1},
+		},
+		{
+			name:  "clear to low res",
+			hires: true,
+			rom:   []byte{0x00, 0xFE},
+			lit:   map[point]byte{{3, 2}: 1},
+		},
+		{
+			name: "clear there and back",
+			rom:  []byte{0x00, 0xFF, 0x00, 0xFE},
+			lit:  map[point]byte{{1, 1}: 1, {63, 31}: 1},
+		},
+		{
+			name:  "clear in the same mode",
+			hires: true,
+			rom:   []byte{0x00, 0xFF},
+			lit:   map[point]byte{{3, 2}: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetQuirks(Quirks{ResolutionKeepsDisplay: tt.keep})
+			p.hires = tt.hires
+			p.Load(tt.rom)
+			setLit(&p, tt.lit)
+
+			for range len(tt.rom) / 2 {
+				step(t, &p)
+			}
+			checkLit(t, &p, tt.want)
+		})
+	}
+}
+
+// BenchmarkDisplay steps a loop of one clear or draw and a jump, with the
+// display stored at one byte per pixel and packed at one bit per pixel.
+func BenchmarkDisplay(b *testing.B) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"clear", []byte{0x00, 0xE0, 0x12, 0x00}}, // CLS; JP 200
+		{"draw", []byte{0xD0, 0x1F, 0x12, 0x00}},  // DRW V0, V1, 15; JP 200
+	}
+
+	for _, tt := range tests {
+		for _, packed := range []bool{false, true} {
+			name := tt.name + "/bytes"
+			if packed {
+				name = tt.name + "/packed"
+			}
+
+			b.Run(name, func(b *testing.B) {
+				var p Processor
+				p.PackedDisplay = packed
+				p.Reset()
+				p.Load(tt.rom)
+
+				for b.Loop() {
+					if _, err := p.Step(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "errors"
+
+// Step returns an *OpcodeError wrapping one of these when a ROM faults, and
+// some other functions return them, so that a host can tell the failures apart
+// with errors.Is, for instance an unknown opcode from a stack underflow.
+var (
+	ErrCycleBudget    = errors.New("chip8: cycle budget exhausted")
+	ErrBreakpoint     = errors.New("chip8: stopped at breakpoint")
+	ErrProgramRunaway = errors.New("chip8: program runaway")
+	ErrStackOverflow  = errors.New("chip8: stack overflow")
+	ErrStackUnderflow = errors.New("chip8: stack underflow")
+	ErrUnknownOpcode  = errors.New("chip8: unknown opcode")
+	ErrROMTooLarge    = errors.New("chip8: rom too large")
+	ErrMemoryRange    = errors.New("chip8: memory out of range")
+	ErrMemoryFault    = errors.New("chip8: memory fault")
+	ErrMisalignedPC   = errors.New("chip8: misaligned program counter")
+	ErrStateFormat    = errors.New("chip8: invalid save state")
+)
+
+// OpcodeError is a fault of the instruction at PC, as returned by Step. Err is
+// the cause, which wraps one of the sentinel errors above, or for a fault this
+// package does not anticipate, the runtime error. Opcode is zero if the fault
+// happened before the instruction could be fetched.
+type OpcodeError struct {
+	PC     uint16
+	Opcode Opcode
+	Err    error
+}
+
+// Error returns the message of the cause, which already includes where the
+// fault happened.
+func (e *OpcodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *OpcodeError) Unwrap() error {
+	return e.Err
+}
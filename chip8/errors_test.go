@@ -0,0 +1,219 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"errors"
+	"testing"
+)
+
+// sentinels lists every sentinel error, so that a test can check an error
+// matches its own and no other.
+var sentinels = []error{
+	ErrCycleBudget, ErrBreakpoint, ErrProgramRunaway, ErrStackOverflow, ErrStackUnderflow, ErrUnknownOpcode,
+	ErrROMTooLarge, ErrMemoryRange, ErrMemoryFault, ErrMisalignedPC, ErrStateFormat,
+}
+
+// checkSentinel fails the test unless err matches want and no other sentinel.
+func checkSentinel(t *testing.T, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Fatalf("error %q does not match %q", err, want)
+	}
+	for _, s := range sentinels {
+		if s != want && errors.Is(err, s) {
+			t.Errorf("error %q also matches %q", err, s)
+		}
+	}
+}
+
+func TestStepFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(p *Processor)
+		want   error
+		pc     uint16
+		opcode Opcode
+	}{
+		{
+			name:   "stack overflow",
+			setup:  func(p *Processor) { p.Load([]byte{0x22, 0x00}) }, // CALL 200
+			want:   ErrStackOverflow,
+			pc:     0x200,
+			opcode: 0x2200,
+		},
+		{
+			name:   "stack underflow",
+			setup:  func(p *Processor) { p.Load([]byte{0x00, 0xEE}) },
+			want:   ErrStackUnderflow,
+			pc:     0x200,
+			opcode: 0x00EE,
+		},
+		{
+			name:   "unknown opcode",
+			setup:  func(p *Processor) { p.Load([]byte{0x60, 0x01, 0xE0, 0x00}) },
+			want:   ErrUnknownOpcode,
+			pc:     0x202,
+			opcode: 0xE000,
+		},
+		{
+			name:  "program runaway",
+			setup: func(p *Processor) { p.WithPC(0xFFF) },
+			want:  ErrProgramRunaway,
+			pc:    0xFFF,
+		},
+		{
+			name:   "memory range",
+			setup:  func(p *Processor) { p.WithIndex(0xFFE).Load([]byte{0xF2, 0x55}) },
+			want:   ErrMemoryRange,
+			pc:     0x200,
+			opcode: 0xF255,
+		},
+		{
+			name: "memory fault",
+			setup: func(p *Processor) {
+				p.Faults = []uint16{0x301}
+				p.WithIndex(0x300).Load([]byte{0xF1, 0x65})
+			},
+			want:   ErrMemoryFault,
+			pc:     0x200,
+			opcode: 0xF165,
+		},
+		{
+			name: "misaligned pc",
+			setup: func(p *Processor) {
+				p.StrictAlignment = true
+				p.Load([]byte{0x12, 0x03}) // JP 203
+			},
+			want: ErrMisalignedPC,
+			pc:   0x203,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			tt.setup(&p)
+
+			var err error
+			for range 20 {
+				if _, err = p.Step(); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				t.Fatal("no fault in 20 steps")
+			}
+			checkSentinel(t, err, tt.want)
+
+			var oe *OpcodeError
+			if !errors.As(err, &oe) {
+				t.Fatalf("error %q is not an *OpcodeError", err)
+			}
+			if oe.PC != tt.pc || oe.Opcode != tt.opcode {
+				t.Errorf("fault of %s at %s, want %s at %s", oe.Opcode, u16toh(oe.PC, 3), tt.opcode, u16toh(tt.pc, 3))
+			}
+		})
+	}
+}
+
+func TestUnhandledOpcodeError(t *testing.T) {
+	var p Processor
+	p.Reset()
+	p.Load([]byte{0x00, 0xE0, 0xF0, 0xFF})
+	step(t, &p)
+
+	_, err := p.Step()
+	checkSentinel(t, err, ErrUnknownOpcode)
+
+	var u UnhandledOpcode
+	if !errors.As(err, &u) {
+		t.Fatalf("error %q is not an UnhandledOpcode", err)
+	}
+	if want := (UnhandledOpcode{Address: 0x202, Opcode: 0xF0FF}); u != want {
+		t.Errorf("got %+v, want %+v", u, want)
+	}
+}
+
+func TestSentinels(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(p *Processor) error
+		want error
+	}{
+		{
+			name: "rom too large",
+			run: func(p *Processor) (err error) {
+				defer func() { err, _ = recover().(error) }()
+				p.Load(make([]byte, MaxROMSize+1))
+				return nil
+			},
+			want: ErrROMTooLarge,
+		},
+		{
+			name: "rom too large, safe",
+			run: func(p *Processor) error {
+				return p.LoadSafe(make([]byte, MaxROMSize+1))
+			},
+			want: ErrROMTooLarge,
+		},
+		{
+			name: "cycle budget",
+			run: func(p *Processor) error {
+				p.Load([]byte{0x12, 0x00})
+				_, err := p.RunUntilBreak(50)
+				return err
+			},
+			want: ErrCycleBudget,
+		},
+		{
+			name: "breakpoint",
+			run: func(p *Processor) error {
+				p.Load([]byte{0x60, 0x01, 0x61, 0x02, 0x12, 0x04})
+				p.AddBreakpoint(0x202)
+				_, err := p.RunUntil(0x204, 50)
+				return err
+			},
+			want: ErrBreakpoint,
+		},
+		{
+			name: "state format",
+			run: func(p *Processor) error {
+				return p.UnmarshalBinary([]byte("CH8S"))
+			},
+			want: ErrStateFormat,
+		},
+		{
+			name: "opcode at end of memory",
+			run: func(p *Processor) error {
+				_, err := p.OpcodeAtSafe(0xFFF)
+				return err
+			},
+			want: ErrProgramRunaway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			checkSentinel(t, tt.run(&p), tt.want)
+		})
+	}
+}
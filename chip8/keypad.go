@@ -0,0 +1,46 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "strings"
+
+// Keypad is the layout of the COSMAC VIP hexadecimal keypad, top row first.
+// Front-ends map their own keys onto it by position, so that programs written
+// for the original hardware keep their controls in the same place.
+var Keypad = [4][4]uint8{
+	{0x1, 0x2, 0x3, 0xC},
+	{0x4, 0x5, 0x6, 0xD},
+	{0x7, 0x8, 0x9, 0xE},
+	{0xA, 0x0, 0xB, 0xF},
+}
+
+// KeypadString renders Keypad as four lines of space separated hex digits.
+func KeypadString() string {
+	var sb strings.Builder
+	for r, row := range Keypad {
+		if r > 0 {
+			sb.WriteByte('\n')
+		}
+		for c, key := range row {
+			if c > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(u8toh(key, 1))
+		}
+	}
+	return sb.String()
+}
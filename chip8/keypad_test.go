@@ -0,0 +1,118 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestWaitForKeyConcurrent runs Fx0A while another goroutine changes the keypad,
+// which is meant to be run with -race. Every key Fx0A reports must be the lowest
+// held key of some state the keypad was actually in.
+func TestWaitForKeyConcurrent(t *testing.T) {
+	tests := []struct {
+		name   string
+		toggle func(p *Processor, n int)
+		valid  map[uint8]bool
+		always bool // Whether some key is held in every state.
+	}{
+		{
+			// Switching from keys 3 and 8 to key 6 alone passes through no
+			// state in which 8 is the lowest held key.
+			name: "mask",
+			toggle: func(p *Processor, n int) {
+				if n%2 == 0 {
+					p.SetKeyMask(1<<3 | 1<<8)
+				} else {
+					p.SetKeyMask(1 << 6)
+				}
+			},
+			valid:  map[uint8]bool{3: true, 6: true},
+			always: true,
+		},
+		{
+			name: "key",
+			toggle: func(p *Processor, n int) {
+				p.SetKey(0xA, n%2 == 0)
+			},
+			valid: map[uint8]bool{0xA: true},
+		},
+		{
+			name: "release",
+			toggle: func(p *Processor, n int) {
+				if n%2 == 0 {
+					p.SetKeyMask(0xFFFF)
+				} else {
+					p.ReleaseKeys()
+				}
+			},
+			valid: map[uint8]bool{0: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.WithMemory(ProgramStartAddress, []byte{0xF5, 0x0A}) // LD V5, K
+
+			started, done := make(chan struct{}), make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tt.toggle(&p, 0)
+				close(started)
+				for n := 1; ; n++ {
+					select {
+					case <-done:
+						return
+					default:
+						tt.toggle(&p, n)
+						runtime.Gosched()
+					}
+				}
+			}()
+			defer func() {
+				close(done)
+				wg.Wait()
+			}()
+			<-started
+
+			pressed := 0
+			for range 10000 {
+				p.WithPC(ProgramStartAddress).WithRegister(5, 0xFF)
+				step(t, &p)
+				runtime.Gosched() // Let the toggling interleave on one CPU.
+
+				if p.ProgramCounter() == ProgramStartAddress {
+					if tt.always {
+						t.Fatal("Fx0A waited while a key was held")
+					}
+					continue // No key was held, so Fx0A waits.
+				}
+				pressed++
+				if got := p.Register(5); !tt.valid[got] {
+					t.Errorf("Fx0A reported key %X", got)
+				}
+			}
+			t.Logf("%d of 10000 steps saw a key", pressed)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// MemorySize is the size of the memory of classic CHIP-8.
+	MemorySize int = 0x1000
+
+	// XOChipMemorySize is the size of memory in XO-CHIP mode.
+	XOChipMemorySize int = 0x10000
+)
+
+// memorySize returns the size of memory: MemorySize, or XOChipMemorySize in
+// XO-CHIP mode.
+func (p *Processor) memorySize() int {
+	if p.XOChip {
+		return XOChipMemorySize
+	}
+	return MemorySize
+}
+
+// mem returns the memory in use. Instructions check their accesses with
+// checkAccess before indexing it, so that one reaching beyond it faults with an
+// error wrapping ErrMemoryRange.
+func (p *Processor) mem() []byte {
+	return p.memory[:p.memorySize()]
+}
+
+// lastAddress returns the last address that Load, Read, and Write reach:
+// LastAddress, or the end of memory in XO-CHIP mode, which reserves none.
+func (p *Processor) lastAddress() uint16 {
+	if p.XOChip {
+		return uint16(XOChipMemorySize - 1)
+	}
+	return LastAddress
+}
+
+// maxROMSize returns the size of the largest program Load accepts.
+func (p *Processor) maxROMSize() int {
+	return int(p.lastAddress()-ProgramStartAddress) + 1
+}
+
+// Region is a labeled range of memory. End is inclusive.
+type Region struct {
+	Start uint16
+	End   uint16
+	Label string
+}
+
+// MemoryMap describes how memory is laid out, in address order, for display
+// alongside a hex dump. The regions cover the whole address space.
+func (p *Processor) MemoryMap() []Region {
+	fontEnd := FontStartAddress + uint16(len(fontSet)) - 1
+	bigFontEnd := BigFontStartAddress + uint16(len(bigFontSet)) - 1
+
+	regions := []Region{
+		{Start: 0x000, End: FontStartAddress - 1, Label: "interpreter"},
+		{Start: FontStartAddress, End: fontEnd, Label: "font"},
+		{Start: BigFontStartAddress, End: bigFontEnd, Label: "big font"},
+		{Start: bigFontEnd + 1, End: ProgramStartAddress - 1, Label: "interpreter"},
+		{Start: ProgramStartAddress, End: p.lastAddress(), Label: "program"},
+	}
+	if end := uint16(p.memorySize() - 1); p.lastAddress() < end {
+		regions = append(regions, Region{Start: p.lastAddress() + 1, End: end, Label: "reserved"})
+	}
+	return regions
+}
+
+// FontIntact reports whether the font regions still hold the glyphs written by
+// Reset. A ROM that overwrites them will draw garbage through Fx29 or Fx30.
+func (p *Processor) FontIntact() bool {
+	return bytes.Equal(p.memory[FontStartAddress:int(FontStartAddress)+len(fontSet)], fontSet) &&
+		bytes.Equal(p.memory[BigFontStartAddress:int(BigFontStartAddress)+len(bigFontSet)], bigFontSet)
+}
+
+// intact returns how many of the n bytes at addr precede the first address
+// listed in Faults.
+func (p *Processor) intact(addr uint16, n int) int {
+	for _, f := range p.Faults {
+		if f >= addr && int(f)-int(addr) < n {
+			n = int(f - addr)
+		}
+	}
+	return n
+}
+
+// fault returns an error wrapping ErrMemoryFault if any of the n bytes at addr
+// is listed in Faults.
+func (p *Processor) fault(addr uint16, n int) error {
+	if k := p.intact(addr, n); k < n {
+		return fmt.Errorf("%w at %s", ErrMemoryFault, u16toh(addr+uint16(k), 3))
+	}
+	return nil
+}
+
+// checkAccess panics if the executing instruction accesses the n bytes at addr
+// and they do not all lie within memory, or one of them is a faulty address.
+func (p *Processor) checkAccess(addr uint16, n int) {
+	if int(addr)+n > p.memorySize() {
+		panic(fmt.Errorf("%w: %d bytes at %s by instruction at %s", ErrMemoryRange, n, u16toh(addr, 3), u16toh(p.here(), 3)))
+	}
+	if err := p.fault(addr, n); err != nil {
+		panic(fmt.Errorf("%w by instruction at %s", err, u16toh(p.here(), 3)))
+	}
+}
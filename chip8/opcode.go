@@ -18,62 +18,154 @@ package chip8
 
 import (
 	"emul8/byteconv"
-	"math/rand/v2"
+	"fmt"
+	"math/bits"
 )
 
 func (p *Processor) clearScreen(info *uint8) {
-	for i := range p.display {
-		p.display[i] = 0
+	p.clearPlanes(p.activePlanes())
+	*info |= Redraw
+}
+
+func (p *Processor) scrollUp(n uint8, info *uint8) {
+	p.scroll(0, -p.scrollDistance(int(n)))
+	*info |= Redraw
+}
+
+func (p *Processor) scrollDown(n uint8, info *uint8) {
+	p.scroll(0, p.scrollDistance(int(n)))
+	*info |= Redraw
+}
+
+func (p *Processor) scrollRight(info *uint8) {
+	p.scroll(p.scrollDistance(4), 0)
+	*info |= Redraw
+}
+
+func (p *Processor) scrollLeft(info *uint8) {
+	p.scroll(-p.scrollDistance(4), 0)
+	*info |= Redraw
+}
+
+// scrollDistance converts a scroll instruction's distance into pixels at the
+// active resolution. With the LowResScrollHalves quirk, the distance is in
+// high resolution pixels, of which two make one in low resolution.
+func (p *Processor) scrollDistance(n int) int {
+	if p.quirks.LowResScrollHalves && !p.hires {
+		return n / 2
+	}
+	return n
+}
+
+// scroll moves the display contents of the active planes dx pixels right and
+// dy pixels down. The vacated pixels are blank, or with the WrapScroll quirk,
+// hold the pixels that were scrolled off the opposite edge.
+func (p *Processor) scroll(dx, dy int) {
+	src := p.pixels()
+	w, h := p.Dimensions()
+	mask := p.activePlanes()
+
+	var dst [HiResArea]byte
+	for y := range h {
+		sy := y - dy
+		if p.quirks.WrapScroll {
+			sy = ((sy % h) + h) % h
+		} else if sy < 0 || sy >= h {
+			continue
+		}
+
+		for x := range w {
+			sx := x - dx
+			if p.quirks.WrapScroll {
+				sx = ((sx % w) + w) % w
+			} else if sx < 0 || sx >= w {
+				continue
+			}
+			dst[y*w+x] = src[sy*w+sx] & mask
+		}
 	}
+
+	// The planes that are not active stay where they are.
+	for i, val := range src {
+		dst[i] |= val &^ mask
+	}
+	p.setPixels(dst[:w*h])
+}
+
+// selectPlanes selects the planes that the drawing, clearing, and scrolling
+// instructions apply to, one bit per plane. Bits beyond the last plane are
+// kept but ignored.
+func (p *Processor) selectPlanes(mask uint8) {
+	p.planes = mask
+}
+
+// loadAudioPattern loads the audio pattern from the AudioPatternSize bytes at I.
+func (p *Processor) loadAudioPattern() {
+	p.checkAccess(p.i, AudioPatternSize)
+	copy(p.pattern[:], p.mem()[p.i:p.i+uint16(AudioPatternSize)])
+	p.hasPattern = true
+}
+
+func (p *Processor) setPitchToX(x uint8) {
+	p.pitch = p.v[x]
+}
+
+func (p *Processor) setHighRes(on bool, info *uint8) {
+	p.setResolution(on)
 	*info |= Redraw
 }
 
 func (p *Processor) callSubroutine(nnn uint16) {
 	if int(p.sp) >= len(p.stack) {
-		panic("stack overflow")
+		panic(fmt.Errorf("%w at %s", ErrStackOverflow, u16toh(p.here(), 3)))
 	}
-	p.stack[p.sp] = p.pc
+	p.stack[p.sp] = p.nextPC()
 	p.sp++
-	p.pc = nnn
+	p.setPC(nnn)
 }
 
 func (p *Processor) returnFromSubroutine() {
 	if p.sp == 0 {
-		panic("stack underflow")
+		panic(fmt.Errorf("%w at %s", ErrStackUnderflow, u16toh(p.here(), 3)))
 	}
 	p.sp--
-	p.pc = p.stack[p.sp]
+	p.setPC(p.stack[p.sp])
 }
 
 func (p *Processor) jumpToLocation(nnn uint16) {
-	p.pc = nnn
+	p.setPC(nnn)
 }
 
 func (p *Processor) jumpWithOffset(nnn uint16) {
-	p.pc = nnn + uint16(p.v[0x0])
+	offset := p.v[0x0]
+	if p.quirks.JumpWithVX {
+		// Read as BXNN, where X is the high nibble of the address.
+		offset = p.v[(nnn>>8)&0xF]
+	}
+	p.setPC(nnn + uint16(offset))
 }
 
 func (p *Processor) stepIfXEqualsNN(x, nn uint8) {
 	if p.v[x] == byte(nn) {
-		p.pc += 2
+		p.skip()
 	}
 }
 
 func (p *Processor) stepIfXNotEqualsNN(x, nn uint8) {
 	if p.v[x] != byte(nn) {
-		p.pc += 2
+		p.skip()
 	}
 }
 
 func (p *Processor) stepIfXEqualsY(x, y uint8) {
 	if p.v[x] == p.v[y] {
-		p.pc += 2
+		p.skip()
 	}
 }
 
 func (p *Processor) stepIfXNotEqualsY(x, y uint8) {
 	if p.v[x] != p.v[y] {
-		p.pc += 2
+		p.skip()
 	}
 }
 
@@ -91,111 +183,262 @@ func (p *Processor) setXToY(x, y uint8) {
 
 func (p *Processor) orXY(x, y uint8) {
 	// This operation traditionally resets the carry flag.
-	p.v[CarryFlag] = 0
+	if !p.quirks.KeepVF {
+		p.v[CarryFlag] = 0
+	}
 	p.v[x] |= p.v[y]
 }
 
 func (p *Processor) andXY(x, y uint8) {
 	// This operation traditionally resets the carry flag.
-	p.v[CarryFlag] = 0
+	if !p.quirks.KeepVF {
+		p.v[CarryFlag] = 0
+	}
 	p.v[x] &= p.v[y]
 }
 
 func (p *Processor) xorXY(x, y uint8) {
 	// This operation traditionally resets the carry flag.
-	p.v[CarryFlag] = 0
+	if !p.quirks.KeepVF {
+		p.v[CarryFlag] = 0
+	}
 	p.v[x] ^= p.v[y]
 }
 
+// The arithmetic and shift operations below write VF after VX, as the
+// hardware does. When X is F, VF therefore ends up holding the flag rather
+// than the result.
+
 func (p *Processor) addXY(x, y uint8) {
 	sum := uint16(p.v[x]) + uint16(p.v[y])
-	// This operation traditionally resets the carry flag.
-	p.v[CarryFlag] = 0
-	if sum > 255 {
-		p.v[CarryFlag] = 1
-	}
 	p.v[x] = byte(sum & 0xFF)
+	p.v[CarryFlag] = byte(sum >> 8)
 }
 
 func (p *Processor) subtractYFromX(x, y uint8) {
-	p.v[CarryFlag] = 0
+	var flag byte
 	if p.v[x] >= p.v[y] {
-		p.v[CarryFlag] = 1
+		flag = 1 // No borrow.
 	}
 	p.v[x] -= p.v[y]
+	p.v[CarryFlag] = flag
 }
 
 func (p *Processor) subtractXFromY(x, y uint8) {
-	p.v[CarryFlag] = 0
+	var flag byte
 	if p.v[y] >= p.v[x] {
-		p.v[CarryFlag] = 1
+		flag = 1 // No borrow.
 	}
 	p.v[x] = p.v[y] - p.v[x]
+	p.v[CarryFlag] = flag
 }
 
-func (p *Processor) shiftRightX(x uint8) {
-	p.v[CarryFlag] = p.v[x] & 0x1
-	p.v[x] >>= 1
+func (p *Processor) shiftRightX(x, y uint8) {
+	val := p.v[x]
+	if p.quirks.ShiftUsesVY {
+		val = p.v[y]
+	}
+	p.v[x] = val >> 1
+	p.v[CarryFlag] = val & 0x1
 }
 
-func (p *Processor) shiftLeftX(x uint8) {
-	p.v[CarryFlag] = (p.v[x] & 0x80) >> 7
-	p.v[x] <<= 1
+func (p *Processor) shiftLeftX(x, y uint8) {
+	val := p.v[x]
+	if p.quirks.ShiftUsesVY {
+		val = p.v[y]
+	}
+	p.v[x] = val << 1
+	p.v[CarryFlag] = (val & 0x80) >> 7
 }
 
 func (p *Processor) setIToNNN(nnn uint16) {
 	p.i = nnn
 }
 
+// setIToLong loads I with the 16-bit address in the word that follows F000,
+// which is part of the instruction, and moves past it.
+func (p *Processor) setIToLong() {
+	addr, err := p.OpcodeAtSafe(p.here() + 2)
+	if err != nil {
+		panic(fmt.Errorf("%w by instruction at %s", err, u16toh(p.here(), 3)))
+	}
+	p.i = uint16(addr)
+	p.pc += 2
+}
+
+// skip skips the instruction after the executing one. In XO-CHIP mode, that
+// may be the four byte F000 NNNN, which is skipped in full.
+func (p *Processor) skip() {
+	if op, ok := p.PeekOpcodeAt(p.nextPC()); ok && p.XOChip && op == 0xF000 {
+		p.pc += 4
+		return
+	}
+	p.pc += 2
+}
+
 func (p *Processor) setXToRandom(x, nn uint8) {
-	randomByte := byte(rand.Uint32N(256))
+	randomByte := p.random()
 	p.v[x] = randomByte & byte(nn)
 }
 
 func (p *Processor) drawSprite(x, y, n uint8, info *uint8) {
-	startX := uint16(p.v[x]) & uint16(Width-1)
-	startY := uint16(p.v[y]) & uint16(Height-1)
+	if p.quirks.DisplayWait {
+		if p.drawn {
+			p.setPC(p.here()) // Wait for the next frame.
+			return
+		}
+		p.drawn = true
+	}
+
+	// The starting coordinate always wraps, which a modulo does for any
+	// resolution, not only those whose dimensions are powers of two.
+	startX := uint16(p.v[x]) % uint16(p.width())
+	startY := uint16(p.v[y]) % uint16(p.height())
 
 	p.v[CarryFlag] = 0 // Reset the collision register.
 
-	for row := range uint16(n) {
-		if startY+row >= uint16(Height) {
-			// Reached the bottom of the display.
-			break
+	// In high resolution, or at either resolution in XO-CHIP mode, DXY0
+	// draws a 16x16 sprite of two bytes per row.
+	size := uint16(n)
+	wide := n == 0 && (p.hires || p.XOChip)
+	if wide {
+		size = 32
+	}
+
+	// Each active plane is drawn with its own sprite, which follow one
+	// another in memory, the first plane's first.
+	planes := p.activePlanes()
+	total := size * uint16(bits.OnesCount8(planes))
+
+	if p.i < p.SpriteGuard && !p.readsFont(p.i, total) {
+		p.warn("sprite read below guard address", "pc", p.here(), "i", p.i, "height", n, "guard", p.SpriteGuard)
+	}
+
+	p.checkAccess(p.i, int(total))
+
+	var collided, below int
+	var clipped bool
+	addr := p.i
+	for plane := range Planes {
+		mask := byte(1) << plane
+		if planes&mask == 0 {
+			continue
 		}
 
-		sprite := p.memory[p.i+row]
+		c, b, cl := p.blit(startX, startY, p.mem()[addr:addr+size], wide, mask)
+		collided, below, clipped = collided+c, b, clipped || cl
+		addr += size
+	}
 
-		for col := range uint16(8) {
-			if startX+col >= uint16(Width) {
+	switch {
+	case p.quirks.CountCollidingRows && p.hires:
+		p.v[CarryFlag] = uint8(collided + below)
+	case collided > 0:
+		p.v[CarryFlag] = 1 // Turn on the collision register.
+	}
+
+	if clipped {
+		p.debug("sprite clipped at display edge", "pc", p.here(), "x", startX, "y", startY, "height", n)
+	}
+	*info |= Redraw
+}
+
+// blit XORs the sprite rows onto the planes of the display set in mask, with
+// its top left corner at startX, startY, applying the wrapping quirks. Rows are
+// one byte, or two when the sprite is wide, 16 pixels across. It reports how
+// many rows turned off a lit pixel, how many fell off the bottom of the
+// display, and whether any part of the sprite fell off the display.
+func (p *Processor) blit(startX, startY uint16, sprite []byte, wide bool, mask byte) (collided, below int, clipped bool) {
+	width, height := uint16(p.width()), uint16(p.height())
+
+	cols, rows := uint16(8), uint16(len(sprite))
+	if wide {
+		cols, rows = 16, rows/2
+	}
+
+	for row := range rows {
+		py := startY + row
+		wrappedY := false
+		if py >= height {
+			if !p.quirks.WrapSprites {
+				// Reached the bottom of the display.
+				below = int(rows - row)
+				clipped = true
 				break
 			}
+			py %= height
+			wrappedY = true
+		}
 
-			if (sprite & (0x80 >> col)) != 0 {
-				index := (startX + col) + ((startY + row) * uint16(Width))
+		bits := uint16(sprite[row]) << 8
+		if wide {
+			bits = uint16(sprite[2*row])<<8 | uint16(sprite[2*row+1])
+		}
 
-				if p.display[index] == 1 {
-					// Pixel was already on. This indicates a graphical object collision.
-					p.v[CarryFlag] = 1 // Turn on the collision register.
+		collision := false
+		for col := range cols {
+			px := startX + col
+			wrapped := wrappedY
+			if px >= width {
+				if !p.quirks.WrapSprites {
+					clipped = clipped || (bits<<col) != 0
+					break
 				}
-				p.display[index] ^= 1
+				px %= width
+				wrapped = true
+			}
+
+			bit := (bits & (0x8000 >> col)) != 0
+			if !bit && p.DrawMode != DrawAND {
+				continue
+			}
+
+			index := int(px) + int(py)*int(width)
+			lit := p.pixel(index)&mask != 0
+
+			var on bool
+			switch p.DrawMode {
+			case DrawOR:
+				on = true
+			case DrawAND:
+				on = lit && bit
+			default:
+				on = !lit
+			}
+
+			if lit && !on && !(wrapped && p.quirks.WrapCollision == WrapCollisionIgnore) {
+				// Pixel was already on. This indicates a graphical object collision.
+				collision = true
 			}
+			if on != lit {
+				p.flipPixel(index, mask)
+			}
+		}
+
+		if collision {
+			collided++
 		}
 	}
-	*info |= Redraw
+	return collided, below, clipped
+}
+
+// readsFont reports whether the n bytes at addr lie within the font set or the
+// big font set, which follows it.
+func (p *Processor) readsFont(addr, n uint16) bool {
+	return addr >= FontStartAddress && addr+n <= BigFontStartAddress+uint16(len(bigFontSet))
 }
 
 func (p *Processor) stepIfKeyDown(x uint8) {
 	key := p.v[x] & 0x0F
-	if p.keyState[key].Load() {
-		p.pc += 2
+	if p.keyDown(key) {
+		p.skip()
 	}
 }
 
 func (p *Processor) stepIfKeyUp(x uint8) {
 	key := p.v[x] & 0x0F
-	if !p.keyState[key].Load() {
-		p.pc += 2
+	if !p.keyDown(key) {
+		p.skip()
 	}
 }
 
@@ -204,19 +447,13 @@ func (p *Processor) setXToDelay(x uint8) {
 }
 
 func (p *Processor) pauseUntilKeyPressed(x uint8) {
-	var keyPressed bool
-
-	for i := range uint8(len(p.keyState)) {
-		if p.keyState[i].Load() {
-			keyPressed = true
-			p.v[x] = i
-			break
-		}
-	}
-
-	if !keyPressed {
-		p.pc -= 2 // Move the program counter back, replaying the last opcode
+	// The lowest numbered key wins when several are held.
+	mask := p.KeyMask()
+	if mask == 0 {
+		p.setPC(p.here()) // Replay this opcode on the next step.
+		return
 	}
+	p.v[x] = uint8(bits.TrailingZeros16(mask))
 }
 
 func (p *Processor) setDelayToX(x uint8) {
@@ -228,7 +465,18 @@ func (p *Processor) setSoundToX(x uint8) {
 }
 
 func (p *Processor) setIToX(x uint8) {
-	p.i += uint16(p.v[x])
+	// The address space is that of the current mode, so that in XO-CHIP mode
+	// only overflowing all 64KB sets VF.
+	sum := int(p.i) + int(p.v[x])
+	p.i = uint16(sum)
+
+	if p.quirks.IndexOverflowSetsVF {
+		if sum > p.memorySize()-1 {
+			p.v[CarryFlag] = 1
+		} else {
+			p.v[CarryFlag] = 0
+		}
+	}
 }
 
 func (p *Processor) setIToSymbol(x uint8) {
@@ -236,6 +484,11 @@ func (p *Processor) setIToSymbol(x uint8) {
 	p.i = FontStartAddress + (digit * 5)
 }
 
+func (p *Processor) setIToBigSymbol(x uint8) {
+	digit := uint16(p.v[x] & 0x0F)
+	p.i = BigFontStartAddress + (digit * 10)
+}
+
 func (p *Processor) binaryCodedDecimal(x uint8) {
 	// Takes the number in register VX (which is one byte, so it can be any number from
 	// 0 to 255) and converts it to three decimal digits, storing these digits in memory
@@ -287,23 +540,56 @@ func (p *Processor) binaryCodedDecimal(x uint8) {
 		bcd = (bcd << 1) | ((val >> (7 - i)) & 1)
 	}
 
-	p.memory[p.i] = byte((bcd >> 8) & 0xF)   // Hundreds
-	p.memory[p.i+1] = byte((bcd >> 4) & 0xF) // Tens
-	p.memory[p.i+2] = byte(bcd & 0xF)        // Ones
+	p.guardCode(p.here(), p.i, 3)
+	p.checkAccess(p.i, 3)
+	mem := p.mem()
+	mem[p.i] = byte((bcd >> 8) & 0xF)   // Hundreds
+	mem[p.i+1] = byte((bcd >> 4) & 0xF) // Tens
+	mem[p.i+2] = byte(bcd & 0xF)        // Ones
 }
 
 func (p *Processor) setRegistersToMemory(x uint8) {
+	p.guardCode(p.here(), p.i, int(x)+1)
+	p.checkAccess(p.i, int(x)+1)
+	mem := p.mem()
 	for i := uint8(0); i <= x; i++ {
-		p.memory[p.i+uint16(i)] = p.v[i]
+		mem[p.i+uint16(i)] = p.v[i]
+	}
+
+	if p.quirks.MemoryIncrementsI {
+		p.i += uint16(x) + 1
 	}
 }
 
 func (p *Processor) setMemoryToRegisters(x uint8) {
+	p.checkAccess(p.i, int(x)+1)
+	mem := p.mem()
 	for i := uint8(0); i <= x; i++ {
-		p.v[i] = p.memory[p.i+uint16(i)]
+		p.v[i] = mem[p.i+uint16(i)]
+	}
+
+	if p.quirks.MemoryIncrementsI {
+		p.i += uint16(x) + 1
 	}
 }
 
+// saveFlags copies V0 through Vx to the RPL user flags. There are only
+// FlagCount flags, so x is clamped to the last of them.
+func (p *Processor) saveFlags(x uint8) {
+	x = min(x, uint8(FlagCount-1))
+	copy(p.rpl[:x+1], p.v[:x+1])
+	if p.flagsHook != nil {
+		p.flagsHook(p.rpl)
+	}
+}
+
+// loadFlags copies the RPL user flags to V0 through Vx, with x clamped as for
+// saveFlags.
+func (p *Processor) loadFlags(x uint8) {
+	x = min(x, uint8(FlagCount-1))
+	copy(p.v[:x+1], p.rpl[:x+1])
+}
+
 type Opcode uint16
 
 func (o Opcode) kind() uint8 {
@@ -338,7 +624,21 @@ func u8toh(i uint8, n int) string {
 	return byteconv.Btoh(byteconv.U16tob(uint16(i)), n)
 }
 
+// String returns the opcode in hexadecimal followed by its mnemonic, such as
+// "0x6A02 LD VA, 02", or just the hexadecimal for opcodes that are unknown.
 func (op Opcode) String() string {
+	hex := "0x" + u16toh(uint16(op), 4)
+
+	str, ok := op.Mnemonic()
+	if !ok {
+		return hex
+	}
+	return hex + " " + str
+}
+
+// Mnemonic returns the assembly form of op, and false if op is not an opcode
+// this package implements.
+func (op Opcode) Mnemonic() (string, bool) {
 	var str string
 
 	switch op.kind() {
@@ -348,8 +648,23 @@ func (op Opcode) String() string {
 			str = "CLS"
 		case 0x00EE:
 			str = "RET"
+		case 0x00FB:
+			str = "SCR"
+		case 0x00FC:
+			str = "SCL"
+		case 0x00FE:
+			str = "LOW"
+		case 0x00FF:
+			str = "HIGH"
 		default:
-			panic("unknown 0x0 opcode")
+			switch uint16(op) & 0xFFF0 {
+			case 0x00C0:
+				str = "SCD " + u8toh(op.n(), 1)
+			case 0x00D0:
+				str = "SCU " + u8toh(op.n(), 1)
+			default:
+				return "", false
+			}
 		}
 	case 0x1:
 		str = "JP " + u16toh(op.nnn(), 3)
@@ -386,7 +701,7 @@ func (op Opcode) String() string {
 		case 0xE:
 			str = "SHL V" + u8toh(op.x(), 1)
 		default:
-			panic("unknown 0x8 opcode")
+			return "", false
 		}
 	case 0x9:
 		str = "SNE V" + u8toh(op.x(), 1) + ", V" + u8toh(op.y(), 1)
@@ -397,7 +712,7 @@ func (op Opcode) String() string {
 	case 0xC:
 		str = "RND V" + u8toh(op.x(), 1) + ", " + u8toh(op.nn(), 2)
 	case 0xD:
-		str = "DRW V" + u8toh(op.x(), 1) + ", V" + u8toh(op.y(), 1) + ", " + u8toh(op.nn(), 2)
+		str = "DRW V" + u8toh(op.x(), 1) + ", V" + u8toh(op.y(), 1) + ", " + u8toh(op.n(), 1)
 	case 0xE:
 		switch op.nn() {
 		case 0x9E:
@@ -405,10 +720,22 @@ func (op Opcode) String() string {
 		case 0xA1:
 			str = "SKNP V" + u8toh(op.x(), 1)
 		default:
-			panic("unknown 0xE opcode")
+			return "", false
 		}
 	case 0xF:
 		switch op.nn() {
+		case 0x00:
+			if op.x() != 0 {
+				return "", false
+			}
+			str = "LD I, LONG"
+		case 0x01:
+			str = "PLANE " + u8toh(op.x(), 1)
+		case 0x02:
+			if op.x() != 0 {
+				return "", false
+			}
+			str = "AUDIO"
 		case 0x07:
 			str = "LD V" + u8toh(op.x(), 1) + ", DT"
 		case 0x0A:
@@ -421,17 +748,25 @@ func (op Opcode) String() string {
 			str = "ADD I, V" + u8toh(op.x(), 1)
 		case 0x29:
 			str = "LD F, V" + u8toh(op.x(), 1)
+		case 0x30:
+			str = "LD HF, V" + u8toh(op.x(), 1)
 		case 0x33:
 			str = "LD B, V" + u8toh(op.x(), 1)
+		case 0x3A:
+			str = "PITCH V" + u8toh(op.x(), 1)
 		case 0x55:
 			str = "LD [I], V" + u8toh(op.x(), 1)
 		case 0x65:
 			str = "LD V" + u8toh(op.x(), 1) + ", [I]"
+		case 0x75:
+			str = "LD R, V" + u8toh(op.x(), 1)
+		case 0x85:
+			str = "LD V" + u8toh(op.x(), 1) + ", R"
 		default:
-			panic("unknown 0xF opcode")
+			return "", false
 		}
 	default:
-		panic("unknown opcode")
+		return "", false
 	}
-	return str
+	return str, true
 }
@@ -0,0 +1,483 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// step executes one instruction, failing the test on a fault.
+func step(t *testing.T, p *Processor) uint8 {
+	t.Helper()
+
+	info, err := p.Step()
+	if err != nil {
+		t.Fatalf("step at %s: %v", u16toh(p.ProgramCounter(), 3), err)
+	}
+	return info
+}
+
+func TestWrapCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      WrapCollision
+		sprite    byte
+		wantFirst uint8
+		wantVF    uint8
+	}{
+		// At x=60 the low nibble of the sprite wraps to the left edge.
+		{"count wrapped", WrapCollisionCount, 0x0F, 0, 1},
+		{"ignore wrapped", WrapCollisionIgnore, 0x0F, 0, 0},
+		{"count straddling", WrapCollisionCount, 0xFF, 0, 1},
+		{"ignore straddling", WrapCollisionIgnore, 0xFF, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetQuirks(Quirks{WrapSprites: true, WrapCollision: tt.mode})
+
+			// Draw the sprite over itself: V0=60, V1=31, I=0x300, D012 twice.
+			p.WithPC(ProgramStartAddress).
+				WithMemory(ProgramStartAddress, []byte{0x60, 60, 0x61, 31, 0xA3, 0x00, 0xD0, 0x12, 0xD0, 0x12}).
+				WithMemory(0x300, []byte{tt.sprite, tt.sprite})
+			for range 4 {
+				step(t, &p)
+			}
+
+			// The second row wraps to the top, so pixel 0,0 is lit.
+			if got := p.Display()[0]; got == 0 {
+				t.Errorf("pixel 0,0 not lit after wrapping draw")
+			}
+			if got := p.Register(CarryFlag); got != tt.wantFirst {
+				t.Errorf("VF after first draw = %d, want %d", got, tt.wantFirst)
+			}
+
+			step(t, &p)
+			if got := p.Register(CarryFlag); got != tt.wantVF {
+				t.Errorf("VF after redraw = %d, want %d", got, tt.wantVF)
+			}
+			for i, px := range p.Display() {
+				if px != 0 {
+					t.Fatalf("pixel %d still lit after redraw", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawStartWraps(t *testing.T) {
+	tests := []struct {
+		name         string
+		hires        bool
+		vx, vy       uint8
+		wantX, wantY int
+	}{
+		{"low res inside", false, 10, 20, 10, 20},
+		{"low res", false, 70, 40, 6, 8},
+		{"high res inside", true, 100, 50, 100, 50},
+		{"high res", true, 130, 70, 2, 6},
+		{"high res far corner", true, 255, 255, 127, 63},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetHighRes(tt.hires)
+
+			// A single pixel sprite at V0, V1, without WrapSprites.
+			p.WithPC(ProgramStartAddress).WithRegister(0, tt.vx).WithRegister(1, tt.vy).WithIndex(0x300).
+				WithMemory(ProgramStartAddress, []byte{0xD0, 0x11}).
+				WithMemory(0x300, []byte{0x80})
+			step(t, &p)
+
+			w, h := p.Dimensions()
+			lit := -1
+			for i, px := range p.Display()[:w*h] {
+				if px != 0 {
+					if lit >= 0 {
+						t.Fatalf("more than one pixel lit")
+					}
+					lit = i
+				}
+			}
+			if x, y := lit%w, lit/w; lit < 0 || x != tt.wantX || y != tt.wantY {
+				t.Errorf("pixel lit at %d, %d, want %d, %d", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestFlagRegisterOperand(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     uint16
+		shiftY bool  // The ShiftUsesVY quirk.
+		vf, v1 uint8 // Initial VF and V1.
+		wantVF uint8
+	}{
+		{"8F06 shifts out 1", 0x8F06, false, 0x03, 0x00, 1},
+		{"8F06 shifts out 0", 0x8F06, false, 0x02, 0x00, 0},
+		{"8F0E shifts out 1", 0x8F0E, false, 0x81, 0x00, 1},
+		{"8F0E shifts out 0", 0x8F0E, false, 0x40, 0x00, 0},
+		{"8F16 shifts VY", 0x8F16, true, 0xFE, 0x01, 1},
+		{"8F1E shifts VY", 0x8F1E, true, 0x01, 0x80, 1},
+		{"8F14 carries", 0x8F14, false, 0xFF, 0x01, 1},
+		{"8F14 does not carry", 0x8F14, false, 0x7F, 0x01, 0},
+		{"8F15 borrows", 0x8F15, false, 0x01, 0x02, 0},
+		{"8F17 does not borrow", 0x8F17, false, 0x01, 0x02, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetQuirks(Quirks{ShiftUsesVY: tt.shiftY})
+			p.WithPC(ProgramStartAddress).WithRegister(CarryFlag, tt.vf).WithRegister(1, tt.v1).
+				WithMemory(ProgramStartAddress, []byte{byte(tt.op >> 8), byte(tt.op)})
+			step(t, &p)
+
+			if got := p.Register(CarryFlag); got != tt.wantVF {
+				t.Errorf("VF = %02X, want %02X", got, tt.wantVF)
+			}
+		})
+	}
+}
+
+// point is a display coordinate.
+type point struct{ x, y int }
+
+// setLit sets the display to the pixel values in lit, at the active
+// resolution, leaving every other pixel unlit.
+func setLit(p *Processor, lit map[point]byte) {
+	w, h := p.Dimensions()
+	display := make([]byte, w*h)
+	for pt, val := range lit {
+		display[pt.y*w+pt.x] = val
+	}
+	p.setPixels(display)
+}
+
+// checkLit fails the test unless the display holds exactly the pixel values
+// in want, with every other pixel unlit.
+func checkLit(t *testing.T, p *Processor, want map[point]byte) {
+	t.Helper()
+
+	w, _ := p.Dimensions()
+	for i, val := range p.Display() {
+		pt := point{i % w, i / w}
+		if val != want[pt] {
+			t.Errorf("pixel %d, %d = %d, want %d", pt.x, pt.y, val, want[pt])
+		}
+	}
+}
+
+func TestScrollUp(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  []byte // Executed before the scroll.
+		quirks Quirks
+		op     uint16
+		lit    map[point]byte
+		want   map[point]byte
+	}{
+		{
+			name: "low res",
+			op:   0x00D3,
+			lit:  map[point]byte{{5, 0}: 1, {6, 3}: 1, {7, 10}: 1, {63, 31}: 1},
+			want: map[point]byte{{6, 0}: 1, {7, 7}: 1, {63, 28}: 1},
+		},
+		{
+			name:  "high res",
+			setup: []byte{0x00, 0xFF},
+			op:    0x00D5,
+			lit:   map[point]byte{{0, 4}: 1, {127, 5}: 1, {64, 63}: 1},
+			want:  map[point]byte{{127, 0}: 1, {64, 58}: 1},
+		},
+		{
+			name: "zero rows",
+			op:   0x00D0,
+			lit:  map[point]byte{{1, 1}: 1, {2, 31}: 1},
+			want: map[point]byte{{1, 1}: 1, {2, 31}: 1},
+		},
+		{
+			name:   "low res halves",
+			quirks: Quirks{LowResScrollHalves: true},
+			op:     0x00D4,
+			lit:    map[point]byte{{3, 2}: 1, {4, 31}: 1},
+			want:   map[point]byte{{3, 0}: 1, {4, 29}: 1},
+		},
+		{
+			// Only the second plane moves. Both are lit at 9, 12.
+			name:  "second plane",
+			setup: []byte{0xF2, 0x01},
+			op:    0x00D2,
+			lit:   map[point]byte{{8, 8}: 1, {9, 12}: 3, {10, 20}: 2},
+			want:  map[point]byte{{8, 8}: 1, {9, 10}: 2, {9, 12}: 1, {10, 18}: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.XOChip = true
+			p.Reset()
+			p.SetQuirks(tt.quirks)
+			p.Load(slices.Concat(tt.setup, []byte{byte(tt.op >> 8), byte(tt.op)}))
+			for range len(tt.setup) / 2 {
+				step(t, &p)
+			}
+
+			setLit(&p, tt.lit)
+			if info := step(t, &p); info&Redraw == 0 {
+				t.Error("scroll did not request a redraw")
+			}
+			checkLit(t, &p, tt.want)
+		})
+	}
+}
+
+func TestScrollWrap(t *testing.T) {
+	lit := map[point]byte{{1, 3}: 1, {10, 4}: 1, {62, 5}: 1}
+
+	tests := []struct {
+		name  string
+		wrap  bool
+		hires bool
+		op    uint16
+		lit   map[point]byte
+		want  map[point]byte
+	}{
+		{"right clear", false, false, 0x00FB, lit, map[point]byte{{5, 3}: 1, {14, 4}: 1}},
+		{"right wrap", true, false, 0x00FB, lit, map[point]byte{{5, 3}: 1, {14, 4}: 1, {2, 5}: 1}},
+		{"left clear", false, false, 0x00FC, lit, map[point]byte{{6, 4}: 1, {58, 5}: 1}},
+		{"left wrap", true, false, 0x00FC, lit, map[point]byte{{61, 3}: 1, {6, 4}: 1, {58, 5}: 1}},
+		{"high res right wrap", true, true, 0x00FB, map[point]byte{{126, 60}: 1}, map[point]byte{{2, 60}: 1}},
+		{"high res left clear", false, true, 0x00FC, map[point]byte{{3, 60}: 1}, map[point]byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetQuirks(Quirks{WrapScroll: tt.wrap})
+			p.SetHighRes(tt.hires)
+			p.Load([]byte{byte(tt.op >> 8), byte(tt.op)})
+
+			setLit(&p, tt.lit)
+			step(t, &p)
+			checkLit(t, &p, tt.want)
+		})
+	}
+}
+
+func TestDrawsPerFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		rom     []byte
+		want    int // Draws in each frame of 100 steps.
+	}{
+		// DRW V0, V1, 1; JP 200
+		{"VIP", ProfileVIP, []byte{0xD0, 0x11, 0x12, 0x00}, 1},
+		{"SUPER-CHIP", ProfileSCHIP, []byte{0xD0, 0x11, 0x12, 0x00}, 50},
+		{"XO-CHIP", ProfileXOCHIP, []byte{0xD0, 0x11, 0x12, 0x00}, 50},
+		// DRW twice in a row; JP 200
+		{"VIP back to back", ProfileVIP, []byte{0xD0, 0x11, 0xD0, 0x11, 0x12, 0x00}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+
+			var p Processor
+			p.Reset()
+			p.SetClock(clock.Now)
+			p.SetProfile(tt.profile)
+			p.Load(tt.rom)
+
+			for frame := range 3 {
+				draws := 0
+				for range 100 {
+					if step(t, &p)&Redraw != 0 {
+						draws++
+					}
+				}
+				if draws != tt.want {
+					t.Errorf("frame %d: %d draws, want %d", frame, draws, tt.want)
+				}
+				clock.Advance(TimerRate)
+			}
+		})
+	}
+}
+
+func TestDrawModes(t *testing.T) {
+	// The second plane is lit at 0 to 3 and 8 on the top row, and the first
+	// plane at 1 and 6, which no mode may touch. The sprite is 1100 1100.
+	lit := map[point]byte{{0, 0}: 2, {1, 0}: 3, {2, 0}: 2, {3, 0}: 2, {6, 0}: 1, {8, 0}: 2}
+
+	tests := []struct {
+		name   string
+		mode   DrawMode
+		want   map[point]byte
+		wantVF uint8
+	}{
+		{"XOR", DrawXOR, map[point]byte{{1, 0}: 1, {2, 0}: 2, {3, 0}: 2, {4, 0}: 2, {5, 0}: 2, {6, 0}: 1, {8, 0}: 2}, 1},
+		{"OR", DrawOR, map[point]byte{{0, 0}: 2, {1, 0}: 3, {2, 0}: 2, {3, 0}: 2, {4, 0}: 2, {5, 0}: 2, {6, 0}: 1, {8, 0}: 2}, 0},
+		{"AND", DrawAND, map[point]byte{{0, 0}: 2, {1, 0}: 3, {6, 0}: 1, {8, 0}: 2}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.XOChip = true
+			p.DrawMode = tt.mode
+			p.Reset()
+
+			// PLANE 2; DRW V0, V0, 1 with I at a sprite of 1100 1100.
+			p.WithIndex(0x300).WithMemory(0x300, []byte{0xCC}).Load([]byte{0xF2, 0x01, 0xD0, 0x01})
+			step(t, &p)
+
+			setLit(&p, lit)
+			step(t, &p)
+			checkLit(t, &p, tt.want)
+			if got := p.Register(CarryFlag); got != tt.wantVF {
+				t.Errorf("VF = %d, want %d", got, tt.wantVF)
+			}
+		})
+	}
+}
+
+func TestLoadStoreIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		op         uint16
+		increments bool // The MemoryIncrementsI quirk.
+		wantI      uint16
+	}{
+		{"Fx55 keeps I", 0xF555, false, 0x300},
+		{"Fx55 increments I", 0xF555, true, 0x306},
+		{"Fx65 keeps I", 0xF565, false, 0x300},
+		{"Fx65 increments I", 0xF565, true, 0x306},
+	}
+
+	values := []byte{0x10, 0x21, 0x32, 0x43, 0x54, 0x65}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.SetQuirks(Quirks{MemoryIncrementsI: tt.increments})
+			p.WithIndex(0x300).WithRegister(6, 0xEE).Load([]byte{byte(tt.op >> 8), byte(tt.op)})
+
+			// Fx55 stores V0 to V5 in memory, and Fx65 loads them from it.
+			store := tt.op&0xFF == 0x55
+			if store {
+				for i, val := range values {
+					p.WithRegister(uint8(i), val)
+				}
+				p.WithMemory(0x306, []byte{0xEE})
+			} else {
+				p.WithMemory(0x300, slices.Concat(values, []byte{0x77}))
+			}
+			step(t, &p)
+
+			mem := make([]byte, 7)
+			p.Read(0x300, mem)
+			regs := p.Registers()
+			if !slices.Equal(mem[:6], values) || !slices.Equal(regs[:6], values) {
+				t.Errorf("memory % X and V0-V5 % X, want both % X", mem[:6], regs[:6], values)
+			}
+			if store && mem[6] != 0xEE {
+				t.Errorf("Fx55 wrote past V5: [306] = %02X", mem[6])
+			}
+			if !store && regs[6] != 0xEE {
+				t.Errorf("Fx65 loaded past V5: V6 = %02X", regs[6])
+			}
+			if got := p.Index(); got != tt.wantI {
+				t.Errorf("Index() = %s, want %s", u16toh(got, 3), u16toh(tt.wantI, 3))
+			}
+		})
+	}
+}
+
+func TestFlagsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		x     uint8
+		saved int // Registers saved, V0 through V(saved-1).
+	}{
+		{"x=0", 0x0, 1},
+		{"x=3", 0x3, 4},
+		{"x=7", 0x7, 8},
+		{"x=8", 0x8, 8},
+		{"x=F", 0xF, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			for i := range uint8(RegisterCount) {
+				p.WithRegister(i, 0x10+i)
+			}
+
+			var hooked [FlagCount]byte
+			p.SetFlagsHook(func(flags [FlagCount]byte) { hooked = flags })
+
+			// Fx75, then Fx85 of the same x once the registers are cleared.
+			rom := []byte{0xF0 | tt.x, 0x75, 0xF0 | tt.x, 0x85}
+			p.Load(rom)
+			step(t, &p)
+
+			var want [FlagCount]byte
+			for i := range tt.saved {
+				want[i] = 0x10 + byte(i)
+			}
+			if got := p.Flags(); got != want {
+				t.Errorf("Flags() = % X, want % X", got, want)
+			}
+			if hooked != want {
+				t.Errorf("hook got % X, want % X", hooked, want)
+			}
+
+			// The flags outlive Reset, as the HP48's outlive the program, and a
+			// fresh processor can be given them back.
+			var q Processor
+			q.Reset()
+			q.SetFlags(hooked)
+			for _, r := range []*Processor{&p, &q} {
+				r.Reset()
+				r.WithMemory(ProgramStartAddress, rom).WithPC(ProgramStartAddress + 2)
+			}
+
+			for _, r := range []*Processor{&p, &q} {
+				step(t, r)
+				var regs [RegisterCount]byte
+				copy(regs[:], want[:tt.saved])
+				if got := r.Registers(); got != regs {
+					t.Errorf("registers after Fx85 = % X, want % X", got, regs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+// Quirks selects between behaviors that CHIP-8 interpreters disagree on. The
+// zero value is the behavior this package has always had: a mix of the COSMAC
+// VIP and CHIP-48 conventions that most classic ROMs tolerate. SetProfile
+// selects the behavior of a specific platform instead.
+type Quirks struct {
+	// ShiftUsesVY makes 8XY6 and 8XYE shift VY and store the result in VX, as
+	// on the COSMAC VIP, instead of shifting VX in place.
+	ShiftUsesVY bool
+
+	// MemoryIncrementsI makes FX55 and FX65 leave I pointing past the last
+	// register stored or loaded, as on the COSMAC VIP, instead of unchanged.
+	MemoryIncrementsI bool
+
+	// JumpWithVX makes BNNN behave as BXNN, jumping to XNN plus VX, as on
+	// CHIP-48 and SUPER-CHIP, instead of to NNN plus V0.
+	JumpWithVX bool
+
+	// KeepVF makes 8XY1, 8XY2, and 8XY3 leave VF untouched, instead of
+	// resetting it to 0 as a side effect, as on the COSMAC VIP.
+	KeepVF bool
+
+	// WrapSprites draws the part of a sprite that extends past the right or
+	// bottom edge of the display on the opposite edge, instead of clipping it.
+	WrapSprites bool
+
+	// WrapCollision selects whether wrapped pixels contribute to VF. It has no
+	// effect unless WrapSprites is set. The modes document which reference
+	// interpreters they match.
+	WrapCollision WrapCollision
+
+	// WrapScroll makes the scroll instructions fill the rows or columns they
+	// vacate with the pixels scrolled off the opposite edge, instead of
+	// clearing them. SUPER-CHIP and Octo both clear, as the instructions are
+	// specified; wrapping is offered for ROMs written against interpreters
+	// that rotate the display instead.
+	WrapScroll bool
+
+	// IncrementAfterExecute advances the program counter after an instruction
+	// executes rather than before, as described by Step. Programs behave the
+	// same either way, but a fault then leaves the program counter on the
+	// faulting instruction, which suits comparing against reference models
+	// that increment late.
+	IncrementAfterExecute bool
+
+	// IndexOverflowSetsVF makes FX1E set VF to 1 when I ends up past the end
+	// of the 12-bit address space, and to 0 otherwise, as the Amiga
+	// interpreter did. In XO-CHIP mode it is the 16-bit address space of its
+	// larger memory instead. Either way I itself only wraps at 16 bits, as the
+	// register is wide, so an instruction that then accesses memory through I
+	// faults with ErrMemoryRange rather than reading the font at the bottom.
+	// Spacefight 2091! depends on the quirk; no other known ROM does.
+	IndexOverflowSetsVF bool
+
+	// DisplayWait limits DXYN to one sprite per 60Hz frame, as on the COSMAC
+	// VIP, which waited for the vertical blank before drawing. A second DXYN
+	// within the same frame stalls, executing again on each Step until the
+	// next timer tick. VIP-era games rely on it for their pacing and run too
+	// fast without it.
+	//
+	// A host honors the wait just by stepping as usual: the program counter
+	// stays on the stalled DXYN, and the frame ends with the next tick of the
+	// timer clock. A host that drives the clock with SetClock must therefore
+	// advance it, by TimerRate per frame, or a second draw stalls forever.
+	DisplayWait bool
+
+	// ResolutionKeepsDisplay makes 00FE and 00FF scale the display to the new
+	// resolution, as on SUPER-CHIP, where the low resolution mode only draws
+	// every pixel twice as large and the screen is left alone. Otherwise the
+	// display is cleared, as XO-CHIP specifies, which is what most ROMs that
+	// switch resolution expect.
+	ResolutionKeepsDisplay bool
+
+	// LowResScrollHalves makes the scroll instructions move the display half
+	// as far in low resolution, as on SUPER-CHIP 1.1, which scrolls its 128x64
+	// screen the same way in both modes. Distances round down, so scrolling
+	// down by one does nothing. Otherwise they scroll by whole pixels of the
+	// active resolution, as XO-CHIP specifies.
+	LowResScrollHalves bool
+
+	// CountCollidingRows makes DXYN in high resolution set VF to the number of
+	// sprite rows that turned off a lit pixel or fell off the bottom of the
+	// display, as SUPER-CHIP 1.1 does, instead of to 1 for any collision.
+	CountCollidingRows bool
+}
+
+// WrapCollision selects how pixels that wrap to the opposite edge of the
+// display are accounted for in the collision flag.
+type WrapCollision uint8
+
+const (
+	// WrapCollisionCount treats a wrapped pixel like any other pixel, so
+	// erasing a lit pixel sets VF. This matches Octo, the XO-CHIP reference
+	// interpreter.
+	WrapCollisionCount WrapCollision = iota
+
+	// WrapCollisionIgnore draws wrapped pixels but never lets them set VF, so
+	// only the unwrapped part of the sprite can report a collision. A sprite
+	// that wraps and overlaps itself therefore does not collide with itself.
+	// No reference interpreter matches it: the COSMAC VIP and SUPER-CHIP clip
+	// sprites rather than wrap them, and Octo counts wrapped pixels. It is
+	// offered for test ROMs written against emulators that ignore them.
+	WrapCollisionIgnore
+)
+
+func (p *Processor) SetQuirks(q Quirks) {
+	p.quirks = q
+}
+
+func (p *Processor) Quirks() Quirks {
+	return p.quirks
+}
+
+// Profile names a platform whose complete set of quirks can be applied in one
+// call with SetProfile.
+type Profile uint8
+
+const (
+	// ProfileVIP is the original CHIP-8 interpreter on the COSMAC VIP.
+	ProfileVIP Profile = iota
+
+	// ProfileSCHIP is SUPER-CHIP 1.1 on the HP 48.
+	ProfileSCHIP
+
+	// ProfileXOCHIP is XO-CHIP, as implemented by Octo.
+	ProfileXOCHIP
+)
+
+// String returns the name of the platform.
+func (p Profile) String() string {
+	switch p {
+	case ProfileVIP:
+		return "CHIP-8"
+	case ProfileSCHIP:
+		return "SUPER-CHIP"
+	case ProfileXOCHIP:
+		return "XO-CHIP"
+	default:
+		return "unknown"
+	}
+}
+
+// Quirks returns the quirks of the platform. The profiles set them as follows:
+//
+//	Quirk                  VIP    SCHIP  XOCHIP
+//	ShiftUsesVY            yes    no     yes
+//	MemoryIncrementsI      yes    no     yes
+//	JumpWithVX             no     yes    no
+//	KeepVF                 no     yes    yes
+//	WrapSprites            no     no     yes
+//	WrapCollision          count  count  count
+//	WrapScroll             no     no     no
+//	IncrementAfterExecute  no     no     no
+//	IndexOverflowSetsVF    no     no     no
+//	DisplayWait            yes    no     no
+//	ResolutionKeepsDisplay no     yes    no
+//	LowResScrollHalves     no     yes    no
+//	CountCollidingRows     no     yes    no
+func (p Profile) Quirks() Quirks {
+	switch p {
+	case ProfileVIP:
+		return Quirks{
+			ShiftUsesVY:       true,
+			MemoryIncrementsI: true,
+			DisplayWait:       true,
+		}
+	case ProfileSCHIP:
+		return Quirks{
+			JumpWithVX:             true,
+			KeepVF:                 true,
+			ResolutionKeepsDisplay: true,
+			LowResScrollHalves:     true,
+			CountCollidingRows:     true,
+		}
+	case ProfileXOCHIP:
+		return Quirks{
+			ShiftUsesVY:       true,
+			MemoryIncrementsI: true,
+			KeepVF:            true,
+			WrapSprites:       true,
+		}
+	default:
+		panic("unknown profile")
+	}
+}
+
+// SetProfile replaces all quirks with those of the platform.
+func (p *Processor) SetProfile(profile Profile) {
+	p.SetQuirks(profile.Quirks())
+}
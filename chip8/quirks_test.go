@@ -0,0 +1,127 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDisplayWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    bool
+		advance time.Duration // Clock advance after the first draw.
+		stalls  int           // Steps of the second draw that stall, of at most 5.
+	}{
+		// The timers tick at the end of a Step, so the Step that takes the
+		// tick still stalls, and the draw executes on the one after.
+		{"same frame", true, 0, 5},
+		{"half a frame", true, TimerRate / 2, 5},
+		{"just short of a frame", true, TimerRate - 1, 5},
+		{"next frame", true, TimerRate, 1},
+		{"frames later", true, 3 * TimerRate, 1},
+		{"quirk off", false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+
+			var p Processor
+			p.Reset()
+			p.SetClock(clock.Now)
+			p.SetQuirks(Quirks{DisplayWait: tt.wait})
+
+			// DRW V0, V1, 1 at 0,0, then V0=8 and the same at 8,0.
+			p.WithIndex(0x300).WithMemory(0x300, []byte{0xFF}).
+				Load([]byte{0xD0, 0x11, 0x60, 0x08, 0xD0, 0x11, 0x12, 0x06})
+			step(t, &p)
+			step(t, &p)
+			clock.Advance(tt.advance)
+
+			// A stalled draw leaves the PC on the DXYN and the display as it
+			// was, on every Step, however often the host steps.
+			stalls := 0
+			for range 5 {
+				if step(t, &p)&Redraw != 0 {
+					break
+				}
+				if got := p.ProgramCounter(); got != 0x204 {
+					t.Fatalf("PC = %s, want the stalled DXYN at 204", u16toh(got, 3))
+				}
+				if got := p.Display()[8]; got != 0 {
+					t.Fatal("stalled draw changed the display")
+				}
+				stalls++
+			}
+			if stalls != tt.stalls {
+				t.Fatalf("%d stalled steps, want %d", stalls, tt.stalls)
+			}
+			if stalls == 5 {
+				// The stall ends with the next frame.
+				clock.Advance(TimerRate)
+				step(t, &p)
+				step(t, &p)
+			}
+
+			if got := p.ProgramCounter(); got != 0x206 {
+				t.Errorf("PC = %s, want 206 after the draw", u16toh(got, 3))
+			}
+			if got := p.Display()[8]; got != 1 {
+				t.Errorf("pixel 8,0 = %d, want 1 after the draw", got)
+			}
+		})
+	}
+}
+
+func TestIndexOverflowSetsVF(t *testing.T) {
+	tests := []struct {
+		name   string
+		quirk  bool
+		xochip bool
+		i      uint16
+		vx     uint8
+		wantI  uint16
+		wantVF uint8 // VF starts at AA.
+	}{
+		{"overflow", true, false, 0xFFF, 0x10, 0x100F, 1},
+		{"last address", true, false, 0xFF0, 0x0F, 0xFFF, 0},
+		{"quirk off", false, false, 0xFFF, 0x10, 0x100F, 0xAA},
+		{"xo-chip past 12 bits", true, true, 0xFFF, 0x10, 0x100F, 0},
+		{"xo-chip overflow", true, true, 0xFFFF, 0x01, 0x0000, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.XOChip = tt.xochip
+			p.Reset()
+			p.SetQuirks(Quirks{IndexOverflowSetsVF: tt.quirk})
+			// ADD I, V3
+			p.WithIndex(tt.i).WithRegister(3, tt.vx).WithRegister(0xF, 0xAA).Load([]byte{0xF3, 0x1E})
+			step(t, &p)
+
+			if got := p.Index(); got != tt.wantI {
+				t.Errorf("I = %s, want %s", u16toh(got, 4), u16toh(tt.wantI, 4))
+			}
+			if got := p.Register(0xF); got != tt.wantVF {
+				t.Errorf("VF = %02X, want %02X", got, tt.wantVF)
+			}
+		})
+	}
+}
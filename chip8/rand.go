@@ -0,0 +1,49 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "math/rand/v2"
+
+// RandSource produces the random numbers of CXNN. Uint32N returns a number in
+// [0, n). *rand.Rand from math/rand/v2 satisfies it, so a seeded PCG or
+// ChaCha8 source gives a reproducible stream, and a ChaCha8 seeded from
+// crypto/rand a varied one.
+type RandSource interface {
+	Uint32N(n uint32) uint32
+}
+
+// Sequence is a RandSource that returns its values in order, each reduced
+// modulo n, starting over after the last. It is meant for tests that need
+// CXNN to produce known numbers. It must not be empty.
+type Sequence struct {
+	Values []uint32
+	next   int
+}
+
+func (s *Sequence) Uint32N(n uint32) uint32 {
+	v := s.Values[s.next%len(s.Values)]
+	s.next++
+	return v % n
+}
+
+// random returns a random byte from the processor's source.
+func (p *Processor) random() byte {
+	if p.Rand != nil {
+		return byte(p.Rand.Uint32N(256))
+	}
+	return byte(rand.Uint32N(256))
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestRandSource(t *testing.T) {
+	var seed [32]byte
+	copy(seed[:], "emul8")
+
+	tests := []struct {
+		name string
+		rand RandSource
+		op   uint16
+		want []byte // V0 after each of three CXNN.
+	}{
+		{"PCG", rand.New(rand.NewPCG(1, 2)), 0xC0FF, []byte{0x10, 0x6C, 0x88}},
+		{"ChaCha8", rand.New(rand.NewChaCha8(seed)), 0xC0FF, []byte{0xD3, 0x4F, 0xCA}},
+		{"PCG masked", rand.New(rand.NewPCG(1, 2)), 0xC00F, []byte{0x00, 0x0C, 0x08}},
+		{"sequence", &Sequence{Values: []uint32{0x1234, 0xFF, 7}}, 0xC0FF, []byte{0x34, 0xFF, 0x07}},
+		{"sequence masked", &Sequence{Values: []uint32{0x1234, 0xFF, 7}}, 0xC0F0, []byte{0x30, 0xF0, 0x00}},
+		{"sequence repeats", &Sequence{Values: []uint32{0xAB}}, 0xC0FF, []byte{0xAB, 0xAB, 0xAB}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.Rand = tt.rand
+
+			hi, lo := byte(tt.op>>8), byte(tt.op)
+			p.Load([]byte{hi, lo, hi, lo, hi, lo})
+			for i, want := range tt.want {
+				step(t, &p)
+				if got := p.Register(0); got != want {
+					t.Errorf("V0 after CXNN %d = %02X, want %02X", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// minFrameDelay is the shortest delay between frames of a recording. Browsers
+// play frames with a shorter delay than 20ms at 100ms instead.
+const minFrameDelay = 20 * time.Millisecond
+
+// recordedFrame is a display sampled by a Recorder, at one byte per pixel.
+type recordedFrame struct {
+	pixels []byte
+	width  int
+	at     time.Time
+}
+
+// Recorder records the display into an animated GIF, such as to share a clip
+// of gameplay. A host samples the display with Sample as it runs, and calls
+// Close to write the GIF. Only frames in which the display changed are kept,
+// each shown for as long as the display stayed the same, and each encoded as
+// just the area that changed, which keeps the file small.
+//
+// The frames are in the colors of the processor's Palette, and are scaled to
+// as wide as the widest frame at the scale given to NewRecorder, so that a ROM
+// may switch resolution during a recording.
+type Recorder struct {
+	w       io.Writer
+	scale   int
+	palette color.Palette
+	frames  []recordedFrame
+	end     time.Time // Of the last sample.
+	closed  bool
+}
+
+// NewRecorder returns a Recorder that writes to w on Close, drawing each
+// display pixel as a block of scale by scale image pixels. A scale below 1
+// means 1.
+func NewRecorder(w io.Writer, scale int) *Recorder {
+	return &Recorder{w: w, scale: max(scale, 1)}
+}
+
+// Sample records the display of p at time at, if info, the info bits returned
+// by Step since the last sample, include Redraw and the display is different
+// from the last frame. The first sample is always recorded. A host samples at
+// most once per TimerRate, which is as often as the display of a CHIP-8 is
+// refreshed. Samples closer together than 20ms replace the last frame, since
+// GIF players do not keep up with them.
+func (r *Recorder) Sample(p *Processor, info uint8, at time.Time) {
+	r.end = at
+	if len(r.frames) > 0 && info&Redraw == 0 {
+		return
+	}
+
+	img := p.Frame()
+	if r.palette == nil {
+		r.palette = img.Palette
+	}
+	width := img.Rect.Dx()
+
+	if n := len(r.frames); n > 0 {
+		last := &r.frames[n-1]
+		if last.width == width && bytes.Equal(last.pixels, img.Pix) {
+			return
+		}
+
+		// The first frame is never replaced, so the recording starts at the
+		// first sample.
+		if n > 1 && at.Sub(last.at) < minFrameDelay {
+			last.pixels, last.width = img.Pix, width
+			return
+		}
+	}
+	r.frames = append(r.frames, recordedFrame{pixels: img.Pix, width: width, at: at})
+}
+
+// Close writes the GIF to the writer given to NewRecorder, showing the last
+// frame until one TimerRate after the last sample. The writer is not closed.
+// Closing a Recorder again does nothing.
+func (r *Recorder) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if len(r.frames) == 0 {
+		return errors.New("chip8: no frames recorded")
+	}
+
+	widest := 0
+	for _, f := range r.frames {
+		widest = max(widest, f.width)
+	}
+
+	// The image is as large as the widest frame at the requested scale, with
+	// the frames of narrower resolutions scaled up to fill it.
+	aspect := r.frames[0].width / (len(r.frames[0].pixels) / r.frames[0].width)
+	width := widest * r.scale
+	height := width / aspect
+
+	anim := gif.GIF{
+		Config: image.Config{ColorModel: r.palette, Width: width, Height: height},
+	}
+
+	// Delays are in hundredths of a second, rounded from the start of the
+	// recording so that rounding errors do not add up.
+	start := r.frames[0].at
+	centis := func(t time.Time) int {
+		return int(t.Sub(start).Round(10*time.Millisecond) / (10 * time.Millisecond))
+	}
+
+	var prev *image.Paletted
+	for i, f := range r.frames {
+		img := image.NewPaletted(image.Rect(0, 0, width, height), r.palette)
+		block := width / f.width
+		for y := range height {
+			src := f.pixels[y/block*f.width:]
+			row := img.Pix[y*img.Stride:]
+			for x := range width {
+				row[x] = src[x/block]
+			}
+		}
+
+		frame := img
+		if prev != nil {
+			frame = img.SubImage(changed(prev, img)).(*image.Paletted)
+		}
+		prev = img
+
+		next := r.end.Add(TimerRate)
+		if i+1 < len(r.frames) {
+			next = r.frames[i+1].at
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, max(centis(next)-centis(f.at), 2))
+	}
+
+	return gif.EncodeAll(r.w, &anim)
+}
+
+// changed returns the smallest rectangle that holds every pixel that differs
+// between two images of the same size, or a single pixel if none do, since a
+// GIF frame cannot be empty.
+func changed(a, b *image.Paletted) image.Rectangle {
+	var r image.Rectangle
+	for y := range b.Rect.Dy() {
+		for x := range b.Rect.Dx() {
+			if a.Pix[y*a.Stride+x] != b.Pix[y*b.Stride+x] {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if r.Empty() {
+		return image.Rect(0, 0, 1, 1)
+	}
+	return r
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Event is a change of a key's state, applied just before the instruction
+// with the given zero-based index in the run executes.
+type Event struct {
+	Cycle int
+	Key   uint8
+	Down  bool
+}
+
+// ReplayInput is everything besides the ROM that a replayed run depends on:
+// the quirks and mode it ran under, the seed of CXNN's random numbers, and the
+// key events. A bug report of a ROM and a ReplayInput is enough to reproduce a
+// fault exactly, whichever profile it was recorded under.
+type ReplayInput struct {
+	Quirks Quirks
+	XOChip bool
+	Seed   uint64
+
+	// Events need not be in order. Events for the same cycle are applied in
+	// the order given.
+	Events []Event
+}
+
+// Replay runs rom for cycles instructions in a freshly reset processor with the
+// quirks and mode of rec, applying its events as it goes, and returns the final
+// state. The run depends on nothing but its arguments: CXNN draws from a PCG
+// seeded with the seed of rec, and the timers follow a simulated clock that
+// advances by ClockRate per instruction rather than wall-clock time. Replaying
+// the same arguments therefore reproduces the same run. A fault stops the run
+// and is returned, as from Step, with the state at the fault. A ROM that does
+// not fit returns the error of LoadSafe before anything runs.
+func Replay(rom []byte, rec ReplayInput, cycles int) (Snapshot, error) {
+	var p Processor
+	p.SetQuirks(rec.Quirks)
+	p.XOChip = rec.XOChip
+	p.Reset()
+	if err := p.LoadSafe(rom); err != nil {
+		return p.Snapshot(), err
+	}
+
+	p.Rand = rand.New(rand.NewPCG(rec.Seed, rec.Seed))
+
+	now := time.Unix(0, 0)
+	p.SetClock(func() time.Time { return now })
+
+	events := slices.Clone(rec.Events)
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return cmp.Compare(a.Cycle, b.Cycle)
+	})
+
+	for cycle := range cycles {
+		for len(events) > 0 && events[0].Cycle <= cycle {
+			p.SetKey(events[0].Key, events[0].Down)
+			events = events[1:]
+		}
+
+		if _, err := p.Step(); err != nil {
+			return p.Snapshot(), err
+		}
+		now = now.Add(ClockRate)
+	}
+	return p.Snapshot(), nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"crypto/sha256"
+	"emul8/byteconv"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// MaxROMSize is the size of the largest program Load accepts, which spans
+// ProgramStartAddress through LastAddress.
+const MaxROMSize int = int(LastAddress-ProgramStartAddress) + 1
+
+// XOChipMaxROMSize is the size of the largest program Load accepts in XO-CHIP
+// mode, which spans ProgramStartAddress through the end of memory.
+const XOChipMaxROMSize int = XOChipMemorySize - int(ProgramStartAddress)
+
+// PadROM returns a copy of b extended with zeros to size bytes. A ROM that is
+// already at least size bytes long is copied unchanged. It panics if size
+// exceeds MaxROMSize, since the result could never be loaded.
+func PadROM(b []byte, size int) []byte {
+	if size > MaxROMSize {
+		panic(fmt.Errorf("%w: padded to %d bytes, at most %d fit", ErrROMTooLarge, size, MaxROMSize))
+	}
+
+	out := slices.Clone(b)
+	if len(out) < size {
+		out = append(out, make([]byte, size-len(out))...)
+	}
+	return out
+}
+
+// TrimROM returns a copy of b truncated to at most size bytes.
+func TrimROM(b []byte, size int) []byte {
+	return slices.Clone(b[:min(len(b), max(size, 0))])
+}
+
+// SwapROM returns a copy of b with the bytes of every 16-bit word swapped, for
+// ROMs produced by tools that wrote opcodes little-endian. An odd trailing
+// byte is copied unchanged.
+func SwapROM(b []byte) []byte {
+	out := slices.Clone(b)
+	for i := 0; i+1 < len(out); i += 2 {
+		word := byteconv.Btou16(out[i:])
+		copy(out[i:], byteconv.U16tob(word>>8|word<<8))
+	}
+	return out
+}
+
+// Fingerprint identifies a ROM by the SHA-256 of its contents, in lower case
+// hexadecimal, the form ROM databases commonly key their entries by.
+func Fingerprint(rom []byte) string {
+	sum := sha256.Sum256(rom)
+	return hex.EncodeToString(sum[:])
+}
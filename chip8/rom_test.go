@@ -0,0 +1,77 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSwapROM(t *testing.T) {
+	tests := []struct {
+		name    string
+		swapped []byte
+		want    []byte
+	}{
+		{"empty", []byte{}, []byte{}},
+		{"one word", []byte{0xE0, 0x00}, []byte{0x00, 0xE0}},
+		{"program", []byte{0x05, 0x61, 0x07, 0x62, 0x24, 0x81, 0x00, 0x12}, []byte{0x61, 0x05, 0x62, 0x07, 0x81, 0x24, 0x12, 0x00}},
+		{"odd trailing byte", []byte{0x05, 0x61, 0xAB}, []byte{0x61, 0x05, 0xAB}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := bytes.Clone(tt.swapped)
+			got := SwapROM(in)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("SwapROM(% X) = % X, want % X", tt.swapped, got, tt.want)
+			}
+			if !bytes.Equal(in, tt.swapped) {
+				t.Errorf("SwapROM modified its argument")
+			}
+			if back := SwapROM(got); !bytes.Equal(back, tt.swapped) {
+				t.Errorf("swapping twice gave % X, want % X", back, tt.swapped)
+			}
+		})
+	}
+}
+
+func TestLoadSwappedROM(t *testing.T) {
+	// LD V1, 5; LD V2, 7; ADD V1, V2; JP 0x200, written little-endian.
+	swapped := []byte{0x05, 0x61, 0x07, 0x62, 0x24, 0x81, 0x00, 0x12}
+
+	var p Processor
+	p.Reset()
+	p.Load(SwapROM(swapped))
+
+	want := []Opcode{0x6105, 0x6207, 0x8124, 0x1200}
+	for i, op := range want {
+		if got := p.OpcodeAt(ProgramStartAddress + uint16(2*i)); got != op {
+			t.Errorf("opcode %d = %s, want %s", i, got, op)
+		}
+	}
+
+	for range len(want) {
+		step(t, &p)
+	}
+	if got := p.Register(1); got != 12 {
+		t.Errorf("V1 = %d, want 12", got)
+	}
+	if got := p.ProgramCounter(); got != ProgramStartAddress {
+		t.Errorf("PC = %s, want %s", u16toh(got, 3), u16toh(ProgramStartAddress, 3))
+	}
+}
@@ -0,0 +1,267 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	glyphWidth  int = 4
+	glyphHeight int = 5
+	glyphChars      = "0123456789ABCDEF"
+)
+
+// DrawMode selects how DXYN combines the pixels of a sprite with the display.
+// Only DrawXOR is what any CHIP-8 platform does, including XO-CHIP, whose
+// planes are each drawn with XOR. The other modes are for experimenting with
+// ROMs written for them.
+type DrawMode uint8
+
+const (
+	// DrawXOR flips every display pixel under a set sprite bit.
+	DrawXOR DrawMode = iota
+
+	// DrawOR turns on every display pixel under a set sprite bit.
+	DrawOR
+
+	// DrawAND turns off every display pixel within the 8 pixel wide sprite
+	// under a clear sprite bit.
+	DrawAND
+)
+
+// DefaultPalette draws unlit pixels black and lit pixels white.
+var DefaultPalette = color.Palette{color.Black, color.White}
+
+// Dimensions returns the width and height of the display at the active
+// resolution: Width by Height, or HiResWidth by HiResHeight once a SUPER-CHIP
+// ROM has switched to high resolution with 00FF. Hosts size their image of the
+// display from it, and check it again after each redraw.
+func (p *Processor) Dimensions() (int, int) {
+	return p.width(), p.height()
+}
+
+// HighRes reports whether the high resolution mode is on.
+func (p *Processor) HighRes() bool {
+	return p.hires
+}
+
+// SetHighRes switches to the high resolution mode, or back to low resolution,
+// exactly as 00FF and 00FE do.
+func (p *Processor) SetHighRes(on bool) {
+	p.setResolution(on)
+}
+
+// Frame renders the display as a paletted image, one image pixel per display
+// pixel, colored by the Palette. Pixel values beyond the end of the palette use
+// its last color. The image does not share memory with the processor, so a
+// sequence of frames can be handed to image/gif to record gameplay.
+func (p *Processor) Frame() *image.Paletted {
+	palette := p.Palette
+	if len(palette) == 0 {
+		palette = DefaultPalette
+	}
+
+	w, h := p.Dimensions()
+	img := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+	last := uint8(len(palette) - 1)
+	for i, val := range p.pixels() {
+		img.Pix[i] = min(val, last)
+	}
+	return img
+}
+
+// ScaledFrame is like Frame, but draws each display pixel as a block of scale
+// by scale image pixels, such as to match the size of a window. A scale below
+// 1 means 1.
+func (p *Processor) ScaledFrame(scale int) *image.Paletted {
+	frame := p.Frame()
+	if scale <= 1 {
+		return frame
+	}
+
+	b := frame.Bounds()
+	img := image.NewPaletted(image.Rect(0, 0, b.Dx()*scale, b.Dy()*scale), frame.Palette)
+	for y := range img.Rect.Dy() {
+		src := frame.Pix[y/scale*frame.Stride:]
+		row := img.Pix[y*img.Stride:]
+		for x := range img.Rect.Dx() {
+			row[x] = src[x/scale]
+		}
+	}
+	return img
+}
+
+// WritePNG encodes the display to w as a PNG, at its active resolution and
+// scaled as by ScaledFrame.
+func (p *Processor) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, p.ScaledFrame(scale))
+}
+
+// SetFrameHook installs hook to be called from Step after every instruction
+// that changes the display, such as to record frames or post-process them. The
+// display is passed at one byte per pixel, w pixels wide and h high, row by row.
+//
+// The hook runs synchronously on the goroutine calling Step, and the display
+// does not change until it returns. It must not retain the slice: a host that
+// hands frames to another goroutine, such as a GUI's render thread, copies them
+// first. A nil hook removes it.
+func (p *Processor) SetFrameHook(hook func(display []byte, w, h int)) {
+	p.frameHook = hook
+}
+
+// DrawTallSprite draws the first rows bytes of data as a sprite at x, y,
+// exactly as Dxyn would, including setting VF on collision, but without the
+// limit of 15 rows. It always draws to the first plane. It is meant for
+// building display fixtures, and panics if data is shorter than rows.
+func (p *Processor) DrawTallSprite(x, y, rows uint8, data []byte) {
+	collided, _, _ := p.blit(uint16(x)%uint16(p.width()), uint16(y)%uint16(p.height()), data[:rows], false, 1)
+
+	p.v[CarryFlag] = 0
+	if collided > 0 {
+		p.v[CarryFlag] = 1
+	}
+}
+
+// DisplayString renders the display as rows of '#' (lit) and '.' (unlit)
+// pixels, top to bottom, separated by newlines, at the active resolution.
+func (p *Processor) DisplayString() string {
+	w, h := p.Dimensions()
+
+	var sb strings.Builder
+	sb.Grow(w*h + h)
+
+	pixels := p.pixels()
+	for y := range h {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+
+		for _, val := range pixels[y*w : (y+1)*w] {
+			if val != 0 {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// SetDisplayString replaces the display with the pixels of s, in the format
+// produced by DisplayString. A single trailing newline is permitted. It returns
+// an error, leaving the display untouched, if s does not have exactly one row
+// per display line and one '#' or '.' per pixel, at the active resolution.
+func (p *Processor) SetDisplayString(s string) error {
+	w, h := p.Dimensions()
+
+	rows := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(rows) != h {
+		return fmt.Errorf("display has %d rows, want %d", len(rows), h)
+	}
+
+	display := make([]byte, w*h)
+	for y, row := range rows {
+		if len(row) != w {
+			return fmt.Errorf("row %d: has %d pixels, want %d", y, len(row), w)
+		}
+
+		for x := range len(row) {
+			switch row[x] {
+			case '#':
+				display[y*w+x] = 1
+			case '.':
+			default:
+				return fmt.Errorf("row %d: invalid pixel %q at column %d", y, row[x], x)
+			}
+		}
+	}
+
+	p.setPixels(display)
+	return nil
+}
+
+// ReadScreenText recognizes the characters of the built-in font drawn on a
+// display buffer at either resolution, as returned by Processor.Display, and
+// returns them as text.
+// Glyphs must be drawn exactly as the font sprites, unclipped and not
+// overlapping other lit pixels within their 4x5 cell. Each distinct row of
+// glyphs becomes a line, ordered top to bottom, and horizontal gaps wider than
+// a glyph become a space. Pixels that are not part of a glyph are ignored.
+func ReadScreenText(display []byte) string {
+	width := Width
+	if len(display) == HiResArea {
+		width = HiResWidth
+	}
+	height := len(display) / width
+
+	type glyph struct {
+		x  int
+		ch byte
+	}
+	lines := make(map[int][]glyph)
+
+	for y := 0; y+glyphHeight <= height; y++ {
+		for x := 0; x+glyphWidth <= width; x++ {
+			var cell [glyphHeight]byte
+			for row := range glyphHeight {
+				for col := range glyphWidth {
+					cell[row] <<= 1
+					if display[(y+row)*width+x+col] != 0 {
+						cell[row] |= 1
+					}
+				}
+				cell[row] <<= 4 // Font sprites occupy the high nibble.
+			}
+
+			for i := range len(glyphChars) {
+				if [glyphHeight]byte(fontSet[i*glyphHeight:]) == cell {
+					lines[y] = append(lines[y], glyph{x: x, ch: glyphChars[i]})
+					x += glyphWidth - 1
+					break
+				}
+			}
+		}
+	}
+
+	ys := make([]int, 0, len(lines))
+	for y := range lines {
+		ys = append(ys, y)
+	}
+	slices.Sort(ys)
+
+	var sb strings.Builder
+	for n, y := range ys {
+		if n > 0 {
+			sb.WriteByte('\n')
+		}
+
+		for i, g := range lines[y] {
+			if i > 0 && g.x-(lines[y][i-1].x+glyphWidth) >= glyphWidth {
+				sb.WriteByte(' ')
+			}
+			sb.WriteByte(g.ch)
+		}
+	}
+	return sb.String()
+}
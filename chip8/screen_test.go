@@ -0,0 +1,123 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"slices"
+	"testing"
+)
+
+func TestFrameGIF(t *testing.T) {
+	green := color.Palette{color.RGBA{0x00, 0x20, 0x00, 0xFF}, color.RGBA{0x40, 0xFF, 0x40, 0xFF}}
+	four := color.Palette{
+		color.RGBA{0x00, 0x00, 0x00, 0xFF},
+		color.RGBA{0xFF, 0x00, 0x00, 0xFF},
+		color.RGBA{0x00, 0x00, 0xFF, 0xFF},
+		color.RGBA{0xFF, 0xFF, 0xFF, 0xFF},
+	}
+
+	tests := []struct {
+		name    string
+		xochip  bool
+		palette color.Palette
+		setup   []byte // Executed before the frames are drawn.
+		lit     uint8  // The pixel value the sprites draw.
+		size    image.Point
+	}{
+		{"default palette", false, nil, nil, 1, image.Pt(Width, Height)},
+		{"custom palette", false, green, nil, 1, image.Pt(Width, Height)},
+		{"high res", false, green, []byte{0x00, 0xFF}, 1, image.Pt(HiResWidth, HiResHeight)},
+		{"second plane", true, four, []byte{0xF2, 0x01}, 2, image.Pt(Width, Height)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.XOChip = tt.xochip
+			p.Palette = tt.palette
+			p.Reset()
+
+			// Three frames: a pixel at 1,1, another at 3,2, and then a clear.
+			rom := slices.Concat(tt.setup, []byte{
+				0x60, 1, 0x61, 1, 0xA3, 0x00, 0xD0, 0x11,
+				0x60, 3, 0x61, 2, 0xD0, 0x11,
+				0x00, 0xE0,
+			})
+			p.WithMemory(0x300, []byte{0x80}).Load(rom)
+
+			for range len(tt.setup) / 2 {
+				step(t, &p)
+			}
+
+			var frames []*image.Paletted
+			for range (len(rom) - len(tt.setup)) / 2 {
+				if step(t, &p)&Redraw != 0 {
+					frames = append(frames, p.Frame())
+				}
+			}
+			if len(frames) != 3 {
+				t.Fatalf("%d frames, want 3", len(frames))
+			}
+
+			palette := tt.palette
+			if palette == nil {
+				palette = DefaultPalette
+			}
+			if got := frames[1].At(3, 2); !sameColor(got, palette[tt.lit]) {
+				t.Errorf("lit pixel is %v, want %v", got, palette[tt.lit])
+			}
+
+			var buf bytes.Buffer
+			anim := gif.GIF{Image: frames, Delay: []int{2, 2, 2}}
+			if err := gif.EncodeAll(&buf, &anim); err != nil {
+				t.Fatal(err)
+			}
+
+			decoded, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded.Image) != len(frames) {
+				t.Fatalf("decoded %d frames, want %d", len(decoded.Image), len(frames))
+			}
+
+			for i, img := range decoded.Image {
+				if got := img.Bounds().Size(); got != tt.size {
+					t.Fatalf("frame %d is %v, want %v", i, got, tt.size)
+				}
+				for y := range tt.size.Y {
+					for x := range tt.size.X {
+						if got, want := img.At(x, y), frames[i].At(x, y); !sameColor(got, want) {
+							t.Fatalf("frame %d pixel %d, %d is %v, want %v", i, x, y, got, want)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// sameColor reports whether two colors are the same, whatever their models.
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snapshot is a copy of the machine state: memory, registers, stack, display,
+// and timers. It holds no references into the processor, so it stays valid
+// however the processor runs on. Configuration and held keys are not part of
+// it.
+type Snapshot struct {
+	// Memory holds the whole of XO-CHIP's memory. All but the first
+	// MemorySize bytes are zero unless the processor is in XO-CHIP mode.
+	Memory [XOChipMemorySize]byte
+	V      [RegisterCount]byte
+
+	// Display holds the pixels row by row at the resolution selected by HiRes,
+	// in its first Area or HiResArea bytes. The rest are zero. Each pixel holds
+	// one bit per plane.
+	Display [HiResArea]byte
+	HiRes   bool
+
+	// Planes holds the planes selected by FN01, one bit per plane. It is 1
+	// unless an XO-CHIP ROM selected others.
+	Planes uint8
+
+	// Pattern holds the audio pattern loaded by F002, if HasPattern is set,
+	// and Pitch the pitch set by FX3A, which is DefaultPitch until then.
+	Pattern    [AudioPatternSize]byte
+	HasPattern bool
+	Pitch      uint8
+
+	Stack [16]uint16
+	SP    uint8
+	PC    uint16
+	I     uint16
+	Delay uint8
+	Sound uint8
+}
+
+// Snapshot captures the current machine state.
+func (p *Processor) Snapshot() Snapshot {
+	s := Snapshot{
+		Memory:     p.memory,
+		V:          p.v,
+		HiRes:      p.hires,
+		Planes:     p.planes,
+		Pattern:    p.pattern,
+		HasPattern: p.hasPattern,
+		Pitch:      p.pitch,
+		Stack:      p.stack,
+		SP:         p.sp,
+		PC:         p.pc,
+		I:          p.i,
+		Delay:      p.delay,
+		Sound:      p.sound,
+	}
+	copy(s.Display[:], p.pixels())
+	return s
+}
+
+// Restore replaces the machine state with s. Execution continues from the
+// saved program counter, and the timers count down from the moment of the
+// next Step, not from when the snapshot was taken. The list of unhandled
+// opcodes is cleared. It returns an error, leaving the machine untouched, if
+// the stack pointer in s is out of range.
+func (p *Processor) Restore(s Snapshot) error {
+	if int(s.SP) > len(s.Stack) {
+		return fmt.Errorf("snapshot stack pointer %d exceeds stack size %d", s.SP, len(s.Stack))
+	}
+
+	p.machine = machine{
+		memory:     s.Memory,
+		v:          s.V,
+		stack:      s.Stack,
+		sp:         s.SP,
+		pc:         s.PC,
+		i:          s.I,
+		delay:      s.Delay,
+		sound:      s.Sound,
+		hires:      s.HiRes,
+		planes:     s.Planes,
+		pattern:    s.Pattern,
+		hasPattern: s.HasPattern,
+		pitch:      s.Pitch,
+	}
+	p.setPixels(s.Display[:p.area()])
+	return nil
+}
+
+// MemoryChange is a byte of memory that differs between two snapshots.
+type MemoryChange struct {
+	Addr     uint16
+	Old, New byte
+}
+
+// RegisterChange is a register that differs between two snapshots. Name is
+// V0 through VF, I, PC, SP, DT, ST, or S0 through SF for the stack entries.
+// Digits is the number of hex digits the register is printed with: three for
+// addresses, and two for byte registers.
+type RegisterChange struct {
+	Name     string
+	Old, New uint16
+	Digits   int
+}
+
+// SnapshotDiff lists what differs between two snapshots, and nothing else.
+type SnapshotDiff struct {
+	// Memory holds the changed bytes, in address order.
+	Memory []MemoryChange
+
+	// Registers holds the changed registers, in the order their names are
+	// listed in RegisterChange.
+	Registers []RegisterChange
+
+	// Pixels is the number of display pixels that differ.
+	Pixels int
+}
+
+// Diff compares snapshot a with the later snapshot b, for pinpointing what an
+// instruction or a sequence of them changed.
+func Diff(a, b Snapshot) SnapshotDiff {
+	var d SnapshotDiff
+
+	for addr := range a.Memory {
+		if a.Memory[addr] != b.Memory[addr] {
+			d.Memory = append(d.Memory, MemoryChange{Addr: uint16(addr), Old: a.Memory[addr], New: b.Memory[addr]})
+		}
+	}
+
+	reg := func(name string, old, new uint16, digits int) {
+		if old != new {
+			d.Registers = append(d.Registers, RegisterChange{Name: name, Old: old, New: new, Digits: digits})
+		}
+	}
+	for i := range a.V {
+		reg("V"+u8toh(uint8(i), 1), uint16(a.V[i]), uint16(b.V[i]), 2)
+	}
+	reg("I", a.I, b.I, 3)
+	reg("PC", a.PC, b.PC, 3)
+	reg("SP", uint16(a.SP), uint16(b.SP), 2)
+	reg("DT", uint16(a.Delay), uint16(b.Delay), 2)
+	reg("ST", uint16(a.Sound), uint16(b.Sound), 2)
+	for i := range a.Stack {
+		reg("S"+u8toh(uint8(i), 1), a.Stack[i], b.Stack[i], 3)
+	}
+
+	for i := range a.Display {
+		if a.Display[i] != b.Display[i] {
+			d.Pixels++
+		}
+	}
+	return d
+}
+
+// Empty reports whether the snapshots were identical.
+func (d SnapshotDiff) Empty() bool {
+	return len(d.Memory) == 0 && len(d.Registers) == 0 && d.Pixels == 0
+}
+
+// String lists the differences one per line, such as
+//
+//	V3: 00 -> 7B
+//	PC: 202 -> 204
+//	[300]: 00 -> 01
+//	display: 12 pixels
+//
+// Registers come first, then memory, then the display.
+func (d SnapshotDiff) String() string {
+	var sb strings.Builder
+
+	for _, r := range d.Registers {
+		fmt.Fprintf(&sb, "%s: %s -> %s\n", r.Name, u16toh(r.Old, r.Digits), u16toh(r.New, r.Digits))
+	}
+
+	for _, m := range d.Memory {
+		fmt.Fprintf(&sb, "[%s]: %s -> %s\n", u16toh(m.Addr, 3), u8toh(m.Old, 2), u8toh(m.New, 2))
+	}
+
+	if d.Pixels > 0 {
+		fmt.Fprintf(&sb, "display: %d pixels\n", d.Pixels)
+	}
+	return sb.String()
+}
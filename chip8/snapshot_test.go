@@ -0,0 +1,212 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"slices"
+	"testing"
+)
+
+// counterROM counts in V0 by calling a subroutine from a loop:
+//
+//	200: LD V0, 0
+//	202: CALL 208
+//	204: JP 202
+//	208: ADD V0, 1
+//	20A: RET
+var counterROM = []byte{0x60, 0x00, 0x22, 0x08, 0x12, 0x02, 0x00, 0x00, 0x70, 0x01, 0x00, 0xEE}
+
+func TestRestoreResumes(t *testing.T) {
+	tests := []struct {
+		name   string
+		before int // Steps before the snapshot.
+		wantPC uint16
+		wantSP uint8
+	}{
+		{"after the first instruction", 1, 0x202, 0},
+		{"inside the subroutine", 3, 0x20A, 1},
+		{"back in the loop", 4, 0x204, 0},
+		{"several iterations in", 10, 0x208, 1},
+	}
+
+	const after = 9 // Steps after the snapshot.
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.Load(counterROM)
+			for range tt.before {
+				step(t, &p)
+			}
+
+			snap := p.Snapshot()
+			if snap.PC != tt.wantPC || snap.SP != tt.wantSP {
+				t.Fatalf("snapshot at PC %s SP %d, want PC %s SP %d", u16toh(snap.PC, 3), snap.SP, u16toh(tt.wantPC, 3), tt.wantSP)
+			}
+
+			for range after {
+				step(t, &p)
+			}
+			want := p.Snapshot()
+
+			// A fresh processor resumes from the saved PC, not from
+			// ProgramStartAddress.
+			var q Processor
+			q.Reset()
+			if err := q.Restore(snap); err != nil {
+				t.Fatal(err)
+			}
+			if got := q.ProgramCounter(); got != tt.wantPC {
+				t.Fatalf("PC after Restore = %s, want %s", u16toh(got, 3), u16toh(tt.wantPC, 3))
+			}
+
+			for range after {
+				step(t, &q)
+			}
+			if d := Diff(want, q.Snapshot()); !d.Empty() {
+				t.Errorf("resumed run differs:\n%s", d)
+			}
+
+			// So does the original, rewound.
+			if err := p.Restore(snap); err != nil {
+				t.Fatal(err)
+			}
+			for range after {
+				step(t, &p)
+			}
+			if d := Diff(want, p.Snapshot()); !d.Empty() {
+				t.Errorf("rewound run differs:\n%s", d)
+			}
+		})
+	}
+}
+
+func TestRestoreBadStackPointer(t *testing.T) {
+	var p Processor
+	p.Reset()
+	p.Load(counterROM)
+	step(t, &p)
+
+	before := p.Snapshot()
+	bad := before
+	bad.SP = 17
+	bad.PC = 0x300
+	if err := p.Restore(bad); err == nil {
+		t.Fatal("Restore accepted a stack pointer of 17")
+	}
+	if d := Diff(before, p.Snapshot()); !d.Empty() {
+		t.Errorf("failed Restore changed the machine:\n%s", d)
+	}
+}
+
+func TestDiffRegisters(t *testing.T) {
+	tests := []struct {
+		name string
+		op   []byte // Executed after LD V0, 5.
+		text string
+	}{
+		{"delay timer", []byte{0xF0, 0x15}, "PC: 202 -> 204\nDT: 00 -> 05\n"},
+		{"sound timer", []byte{0xF0, 0x18}, "PC: 202 -> 204\nST: 00 -> 05\n"},
+		{"index", []byte{0xA2, 0xA0}, "I: 000 -> 2A0\nPC: 202 -> 204\n"},
+		{"byte register", []byte{0x71, 0xFF}, "V1: 00 -> FF\nPC: 202 -> 204\n"},
+		{"call", []byte{0x23, 0x00}, "PC: 202 -> 300\nSP: 00 -> 01\nS0: 000 -> 204\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			p.Load(slices.Concat([]byte{0x60, 0x05}, tt.op))
+			step(t, &p)
+
+			before := p.Snapshot()
+			step(t, &p)
+			if got := Diff(before, p.Snapshot()).String(); got != tt.text {
+				t.Errorf("String() = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+func TestDiffBCD(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  uint8
+		memory []byte // At 300 before the instruction.
+		want   []MemoryChange
+		text   string
+	}{
+		{
+			name:  "three digits",
+			value: 123,
+			want:  []MemoryChange{{0x300, 0, 1}, {0x301, 0, 2}, {0x302, 0, 3}},
+			text:  "PC: 202 -> 204\n[300]: 00 -> 01\n[301]: 00 -> 02\n[302]: 00 -> 03\n",
+		},
+		{
+			name:  "one digit",
+			value: 7,
+			want:  []MemoryChange{{0x302, 0, 7}},
+			text:  "PC: 202 -> 204\n[302]: 00 -> 07\n",
+		},
+		{
+			name:   "digits already there",
+			value:  200,
+			memory: []byte{2, 0, 0},
+			text:   "PC: 202 -> 204\n",
+		},
+		{
+			name:   "overwrites",
+			value:  45,
+			memory: []byte{9, 9, 9},
+			want:   []MemoryChange{{0x300, 9, 0}, {0x301, 9, 4}, {0x302, 9, 5}},
+			text:   "PC: 202 -> 204\n[300]: 09 -> 00\n[301]: 09 -> 04\n[302]: 09 -> 05\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Processor
+			p.Reset()
+			// LD V3, value; LD B, V3
+			p.WithIndex(0x300).WithMemory(0x300, PadROM(tt.memory, 3)).Load([]byte{0x63, tt.value, 0xF3, 0x33})
+			step(t, &p)
+
+			before := p.Snapshot()
+			step(t, &p)
+			d := Diff(before, p.Snapshot())
+
+			if !slices.Equal(d.Memory, tt.want) {
+				t.Errorf("Memory = %v, want %v", d.Memory, tt.want)
+			}
+			if want := []RegisterChange{{"PC", 0x202, 0x204, 3}}; !slices.Equal(d.Registers, want) {
+				t.Errorf("Registers = %v, want %v", d.Registers, want)
+			}
+			if d.Pixels != 0 || d.Empty() {
+				t.Errorf("Pixels = %d, Empty() = %t", d.Pixels, d.Empty())
+			}
+			if got := d.String(); got != tt.text {
+				t.Errorf("String() = %q, want %q", got, tt.text)
+			}
+		})
+	}
+
+	var p Processor
+	p.Reset()
+	if d := Diff(p.Snapshot(), p.Snapshot()); !d.Empty() || d.String() != "" {
+		t.Errorf("Diff of identical snapshots = %q", d)
+	}
+}
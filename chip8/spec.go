@@ -0,0 +1,123 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import "slices"
+
+// OpcodeSpec describes an instruction this package implements.
+type OpcodeSpec struct {
+	// Pattern is the opcode in hexadecimal, with its operands written as X and
+	// Y for registers, N for a nibble, NN for a byte, and NNN for an address.
+	Pattern string
+
+	// Mnemonic is the assembly form, with the operands written as in Pattern.
+	Mnemonic string
+
+	// Extension is the earliest platform that has the instruction.
+	Extension Profile
+}
+
+// opcodeSpecs lists the instructions in opcode order. It must be kept in step
+// with Execute and Mnemonic.
+var opcodeSpecs = []OpcodeSpec{
+	{"00CN", "SCD N", ProfileSCHIP},
+	{"00DN", "SCU N", ProfileXOCHIP},
+	{"00E0", "CLS", ProfileVIP},
+	{"00EE", "RET", ProfileVIP},
+	{"00FB", "SCR", ProfileSCHIP},
+	{"00FC", "SCL", ProfileSCHIP},
+	{"00FE", "LOW", ProfileSCHIP},
+	{"00FF", "HIGH", ProfileSCHIP},
+	{"1NNN", "JP NNN", ProfileVIP},
+	{"2NNN", "CALL NNN", ProfileVIP},
+	{"3XNN", "SE VX, NN", ProfileVIP},
+	{"4XNN", "SNE VX, NN", ProfileVIP},
+	{"5XY0", "SE VX, VY", ProfileVIP},
+	{"6XNN", "LD VX, NN", ProfileVIP},
+	{"7XNN", "ADD VX, NN", ProfileVIP},
+	{"8XY0", "LD VX, VY", ProfileVIP},
+	{"8XY1", "OR VX, VY", ProfileVIP},
+	{"8XY2", "AND VX, VY", ProfileVIP},
+	{"8XY3", "XOR VX, VY", ProfileVIP},
+	{"8XY4", "ADD VX, VY", ProfileVIP},
+	{"8XY5", "SUB VX, VY", ProfileVIP},
+	{"8XY6", "SHR VX", ProfileVIP},
+	{"8XY7", "SUBN VX, VY", ProfileVIP},
+	{"8XYE", "SHL VX", ProfileVIP},
+	{"9XY0", "SNE VX, VY", ProfileVIP},
+	{"ANNN", "LD I, NNN", ProfileVIP},
+	{"BNNN", "JP V0, NNN", ProfileVIP},
+	{"CXNN", "RND VX, NN", ProfileVIP},
+	{"DXYN", "DRW VX, VY, N", ProfileVIP},
+	{"EX9E", "SKP VX", ProfileVIP},
+	{"EXA1", "SKNP VX", ProfileVIP},
+	{"F000", "LD I, LONG", ProfileXOCHIP},
+	{"FN01", "PLANE N", ProfileXOCHIP},
+	{"F002", "AUDIO", ProfileXOCHIP},
+	{"FX07", "LD VX, DT", ProfileVIP},
+	{"FX0A", "LD VX, K", ProfileVIP},
+	{"FX15", "LD DT, VX", ProfileVIP},
+	{"FX18", "LD ST, VX", ProfileVIP},
+	{"FX1E", "ADD I, VX", ProfileVIP},
+	{"FX29", "LD F, VX", ProfileVIP},
+	{"FX30", "LD HF, VX", ProfileSCHIP},
+	{"FX33", "LD B, VX", ProfileVIP},
+	{"FX3A", "PITCH VX", ProfileXOCHIP},
+	{"FX55", "LD [I], VX", ProfileVIP},
+	{"FX65", "LD VX, [I]", ProfileVIP},
+	{"FX75", "LD R, VX", ProfileSCHIP},
+	{"FX85", "LD VX, R", ProfileSCHIP},
+}
+
+// SupportedOpcodes lists every instruction this package implements, in opcode
+// order, so that a front-end can show which extensions are available.
+func SupportedOpcodes() []OpcodeSpec {
+	return slices.Clone(opcodeSpecs)
+}
+
+// matches reports whether op is an instance of the instruction, that is,
+// whether it has the hexadecimal digits of Pattern wherever Pattern does not
+// name an operand.
+func (s OpcodeSpec) matches(op Opcode) bool {
+	for i := range 4 {
+		c := s.Pattern[i]
+		if c == 'X' || c == 'Y' || c == 'N' {
+			continue
+		}
+
+		digit := uint16(op) >> (12 - 4*i) & 0xF
+		want := uint16(c - '0')
+		if c >= 'A' {
+			want = uint16(c-'A') + 0xA
+		}
+		if digit != want {
+			return false
+		}
+	}
+	return true
+}
+
+// specFor returns the instruction op is an instance of, or false if it is not
+// a known instruction.
+func specFor(op Opcode) (OpcodeSpec, bool) {
+	for _, s := range opcodeSpecs {
+		if s.matches(op) {
+			return s, true
+		}
+	}
+	return OpcodeSpec{}, false
+}
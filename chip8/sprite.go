@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseSprite packs a sprite drawn as rows of '.' (off) and '#' (on) characters
+// into the bytes a Dxyn instruction reads. Each non-blank line is one row and
+// every row must be 8 pixels wide, or 16 pixels wide for SUPER-CHIP sprites,
+// in which case each row packs into two bytes. Leading and trailing spaces are
+// ignored. Errors report the 1-based line number of the offending row within
+// src, so an assembler can offset them to the line of its SPRITE directive.
+func ParseSprite(src string) ([]byte, error) {
+	var (
+		out   []byte
+		width int
+	)
+
+	for i, line := range strings.Split(src, "\n") {
+		row := strings.TrimSpace(line)
+		if row == "" {
+			continue
+		}
+
+		if len(row) != 8 && len(row) != 16 {
+			return nil, fmt.Errorf("line %d: sprite row is %d pixels wide, want 8 or 16", i+1, len(row))
+		}
+
+		if width == 0 {
+			width = len(row)
+		} else if len(row) != width {
+			return nil, fmt.Errorf("line %d: sprite row is %d pixels wide, previous rows are %d", i+1, len(row), width)
+		}
+
+		var bits uint16
+		for col := range len(row) {
+			bits <<= 1
+			switch row[col] {
+			case '#':
+				bits |= 1
+			case '.':
+			default:
+				return nil, fmt.Errorf("line %d: invalid sprite character %q", i+1, row[col])
+			}
+		}
+
+		if width == 16 {
+			out = append(out, byte(bits>>8))
+		}
+		out = append(out, byte(bits))
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("sprite has no rows")
+	}
+	return out, nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// stateMagic opens every save state, ahead of the version byte.
+var stateMagic = []byte("CH8S")
+
+// stateVersion follows the magic in the binary save state. It must change
+// whenever the layout below does, and states of other versions are rejected.
+const stateVersion byte = 1
+
+// stateHeader is the length of a save state up to the display: the magic, the
+// version, memory, V0 through VF, the stack, SP, DT, ST, PC, I, the flags, the
+// key mask, the timer phase, the planes selected by FN01, the pitch, and the
+// audio pattern.
+const stateHeader = 4 + 1 + MemorySize + RegisterCount + 16*2 + 3 + 2*2 + 1 + 2 + 4 + 1 + 1 + AudioPatternSize
+
+// The bits of the flags byte.
+const (
+	stateHiRes    byte = 1 << iota // The display is in high resolution.
+	stateDrawn                     // A sprite was drawn since the last tick.
+	statePhase                     // The timer phase is set.
+	statePlane2                    // The second plane follows the first.
+	stateXOMemory                  // The rest of XO-CHIP's memory follows the display.
+	statePattern                   // F002 loaded the audio pattern.
+
+	stateFlags = stateHiRes | stateDrawn | statePhase | statePlane2 | stateXOMemory | statePattern
+)
+
+// MarshalBinary encodes the machine state, as captured by Snapshot, along with
+// the held keys and the time into the current timer tick, in a compact
+// versioned format of 4 to 5KB, most of which is memory. The display is packed
+// at one bit per pixel, at its active resolution, one plane after another, but
+// leaving out the second plane if it is blank, as it is for all but XO-CHIP
+// ROMs. In XO-CHIP mode, the memory beyond MemorySize follows, which makes the
+// state about 65KB. Restoring the state with
+// UnmarshalBinary resumes the machine as it was, so that the next Step behaves
+// exactly as it would have without the round trip.
+func (p *Processor) MarshalBinary() ([]byte, error) {
+	s := p.Snapshot()
+	area := p.area()
+
+	b := make([]byte, 0, stateHeader+area/8)
+	b = append(b, stateMagic...)
+	b = append(b, stateVersion)
+	b = append(b, s.Memory[:MemorySize]...)
+	b = append(b, s.V[:]...)
+	for _, addr := range s.Stack {
+		b = binary.BigEndian.AppendUint16(b, addr)
+	}
+	b = append(b, s.SP, s.Delay, s.Sound)
+	b = binary.BigEndian.AppendUint16(b, s.PC)
+	b = binary.BigEndian.AppendUint16(b, s.I)
+
+	// The phase is how far into the current tick the timers are, so that they
+	// tick at the same moment relative to the next Step after a restore. It
+	// is unset when the timers were just synced.
+	var flags byte
+	var phase time.Duration
+	if s.HiRes {
+		flags |= stateHiRes
+	}
+	if p.XOChip {
+		flags |= stateXOMemory
+	}
+	if p.drawn {
+		flags |= stateDrawn
+	}
+	if s.HasPattern {
+		flags |= statePattern
+	}
+	if !p.lastTimerUpdate.IsZero() {
+		flags |= statePhase
+		phase = min(max(p.now().Sub(p.lastTimerUpdate), 0), p.TimerRate()-1)
+	}
+
+	packed := make([]byte, Planes*area/8)
+	for i, val := range s.Display[:area] {
+		for plane := range Planes {
+			if val&(1<<plane) != 0 {
+				packed[plane*area/8+i/8] |= 0x80 >> (i % 8)
+			}
+		}
+	}
+	if slices.ContainsFunc(packed[area/8:], func(b byte) bool { return b != 0 }) {
+		flags |= statePlane2
+	} else {
+		packed = packed[:area/8]
+	}
+
+	b = append(b, flags)
+	b = binary.BigEndian.AppendUint16(b, p.KeyMask())
+	b = binary.BigEndian.AppendUint32(b, uint32(phase))
+	b = append(b, s.Planes, s.Pitch)
+	b = append(b, s.Pattern[:]...)
+	b = append(b, packed...)
+	if flags&stateXOMemory != 0 {
+		b = append(b, s.Memory[MemorySize:]...)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores the machine state from data produced by
+// MarshalBinary, as Restore does, along with the held keys and the timer
+// phase. It returns an error wrapping ErrStateFormat, leaving the machine
+// untouched, if data is truncated, has another version, holds an invalid state,
+// or holds XO-CHIP's memory while the processor is not in XO-CHIP mode.
+func (p *Processor) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, stateMagic) {
+		return fmt.Errorf("%w: missing header", ErrStateFormat)
+	}
+	if len(data) <= len(stateMagic) {
+		return fmt.Errorf("%w: no version", ErrStateFormat)
+	}
+	if version := data[len(stateMagic)]; version != stateVersion {
+		return fmt.Errorf("%w: unknown version %d", ErrStateFormat, version)
+	}
+	if len(data) < stateHeader {
+		return fmt.Errorf("%w: %d bytes, want at least %d", ErrStateFormat, len(data), stateHeader)
+	}
+	b := data[len(stateMagic)+1:]
+
+	var s Snapshot
+	b = b[copy(s.Memory[:MemorySize], b):]
+	b = b[copy(s.V[:], b):]
+	for i := range s.Stack {
+		s.Stack[i] = binary.BigEndian.Uint16(b)
+		b = b[2:]
+	}
+	s.SP, s.Delay, s.Sound = b[0], b[1], b[2]
+	s.PC = binary.BigEndian.Uint16(b[3:])
+	s.I = binary.BigEndian.Uint16(b[5:])
+	b = b[7:]
+
+	flags := b[0]
+	if flags&^stateFlags != 0 {
+		return fmt.Errorf("%w: unknown flags %02X", ErrStateFormat, flags)
+	}
+	if flags&stateXOMemory != 0 && !p.XOChip {
+		return fmt.Errorf("%w: XO-CHIP state for a processor not in XO-CHIP mode", ErrStateFormat)
+	}
+	s.HiRes = flags&stateHiRes != 0
+	s.HasPattern = flags&statePattern != 0
+
+	keys := binary.BigEndian.Uint16(b[1:])
+	// The phase is less than a tick of the rate it was saved at, which need
+	// not be the rate it is restored at.
+	phase := min(time.Duration(binary.BigEndian.Uint32(b[3:])), p.TimerRate()-1)
+	s.Planes, s.Pitch = b[7], b[8]
+	b = b[9+copy(s.Pattern[:], b[9:]):]
+
+	area := Area
+	if s.HiRes {
+		area = HiResArea
+	}
+	planes := 1
+	if flags&statePlane2 != 0 {
+		planes = 2
+	}
+	extra := 0
+	if flags&stateXOMemory != 0 {
+		extra = XOChipMemorySize - MemorySize
+	}
+	if len(b) != planes*area/8+extra {
+		return fmt.Errorf("%w: %d bytes, want %d", ErrStateFormat, len(data), stateHeader+planes*area/8+extra)
+	}
+	copy(s.Memory[MemorySize:], b[planes*area/8:])
+
+	for plane := range planes {
+		for i := range area {
+			s.Display[i] |= (b[plane*area/8+i/8] >> (7 - i%8)) & 1 << plane
+		}
+	}
+
+	if err := p.Restore(s); err != nil {
+		return fmt.Errorf("%w: %w", ErrStateFormat, err)
+	}
+	p.drawn = flags&stateDrawn != 0
+	if flags&statePhase != 0 {
+		p.lastTimerUpdate = p.now().Add(-phase)
+	}
+	p.SetKeyMask(keys)
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// stateROMs are programs that leave the machine in a state worth saving after
+// stateSteps steps, with the timers running, a sprite drawn, and a subroutine
+// called.
+var stateROMs = map[string][]byte{
+	"classic": {
+		0x60, 0x05, 0xF0, 0x15, 0xF0, 0x18, 0xF0, 0x29, // V0=5, DT=ST=5, I=font 5
+		0xD1, 0x15, 0x22, 0x0E, 0x12, 0x0C, // DRW V1, V1, 5; CALL 20E
+		0x71, 0x01, 0x12, 0x0E, // 20E: V1++ forever
+	},
+	"high res": {
+		0x00, 0xFF, 0x60, 0x05, 0xF0, 0x15, 0xF0, 0x29, // HIGH; V0=5, DT=5, I=font 5
+		0x61, 0x7C, 0xD1, 0x15, 0x22, 0x10, 0x00, 0x00, // V1=7C; DRW (clipped)
+		0x71, 0x01, 0x12, 0x10, // 210: V1++ forever
+	},
+	"XO-CHIP": {
+		0x60, 0x05, 0xF0, 0x29, 0xF3, 0x01, 0xD1, 0x15, // I=font 5; PLANE 3; DRW
+		0xF0, 0x00, 0x80, 0x00, 0x61, 0xAA, 0xF1, 0x55, // I=8000; V1=AA; save V0-V1
+		0xF0, 0x02, 0xF0, 0x15, 0x12, 0x14, // AUDIO; DT=5; loop
+	},
+}
+
+const stateSteps = 8
+
+// stateProcessor returns a processor that has run the named state ROM for
+// stateSteps steps under clock, with key 7 held.
+func stateProcessor(t *testing.T, name string, clock *fakeClock) *Processor {
+	t.Helper()
+
+	var p Processor
+	p.XOChip = name == "XO-CHIP"
+	p.Reset()
+	p.SetClock(clock.Now)
+	p.Load(stateROMs[name])
+	for range stateSteps {
+		clock.Advance(TimerRate / 3)
+		step(t, &p)
+	}
+	p.SetKey(7, true)
+	return &p
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	for _, name := range []string{"classic", "high res", "XO-CHIP"} {
+		t.Run(name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			p := stateProcessor(t, name, clock)
+
+			data, err := p.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var q Processor
+			q.XOChip = p.XOChip
+			q.Reset()
+			q.SetClock(clock.Now)
+			if err := q.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+
+			if d := Diff(p.Snapshot(), q.Snapshot()); !d.Empty() || p.Snapshot() != q.Snapshot() {
+				t.Fatalf("restored state differs:\n%s", d)
+			}
+			if q.KeyMask() != p.KeyMask() {
+				t.Errorf("KeyMask() = %04X, want %04X", q.KeyMask(), p.KeyMask())
+			}
+			if again, _ := q.MarshalBinary(); !slices.Equal(again, data) {
+				t.Errorf("state of the restored machine differs")
+			}
+
+			// Both run on identically, timers included.
+			for n := range 10 {
+				clock.Advance(TimerRate / 3)
+				step(t, p)
+				step(t, &q)
+				if d := Diff(p.Snapshot(), q.Snapshot()); !d.Empty() {
+					t.Fatalf("step %d after restore differs:\n%s", n, d)
+				}
+			}
+		})
+	}
+}
+
+func TestStateErrors(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	good, err := stateProcessor(t, "classic", clock).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	xo, err := stateProcessor(t, "XO-CHIP", clock).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const sp = 4 + 1 + MemorySize + RegisterCount + 16*2
+	const flags = sp + 3 + 2*2
+
+	// corrupt returns a copy of good with the byte at i set to b.
+	corrupt := func(i int, b byte) []byte {
+		data := slices.Clone(good)
+		data[i] = b
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"magic only", good[:4]},
+		{"wrong magic", corrupt(0, 'X')},
+		{"old version", corrupt(4, 0)},
+		{"future version", corrupt(4, stateVersion+1)},
+		{"short header", good[:stateHeader-1]},
+		{"short display", good[:len(good)-1]},
+		{"trailing byte", append(slices.Clone(good), 0)},
+		{"unknown flags", corrupt(flags, good[flags]|0x80)},
+		{"missing second plane", corrupt(flags, good[flags]|statePlane2)},
+		{"stack pointer", corrupt(sp, 17)},
+		{"XO-CHIP memory", xo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stateProcessor(t, "classic", &fakeClock{now: time.Unix(0, 0)})
+			before := p.Snapshot()
+
+			err := p.UnmarshalBinary(tt.data)
+			if !errors.Is(err, ErrStateFormat) {
+				t.Fatalf("UnmarshalBinary returned %v, want ErrStateFormat", err)
+			}
+			if d := Diff(before, p.Snapshot()); !d.Empty() {
+				t.Errorf("failed UnmarshalBinary changed the machine:\n%s", d)
+			}
+		})
+	}
+}
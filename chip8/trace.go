@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"emul8/byteconv"
+	"io"
+)
+
+// TraceEntry is the machine state at the moment an instruction is fetched,
+// before it executes.
+type TraceEntry struct {
+	PC     uint16
+	Opcode Opcode
+	I      uint16
+	V      [RegisterCount]byte
+	SP     uint8
+}
+
+// String formats the entry as a trace line with the fixed layout
+//
+//	PPPP: OOOO  I:IIII  V:00 11 22 33 44 55 66 77 88 99 AA BB CC DD EE FF  SP:SS
+//
+// where every field is upper-case hexadecimal: P is the program counter, O the
+// opcode, I the index register, V0 through VF the registers in order, and S the
+// stack depth. The layout is stable so traces can be diffed against the output
+// of other emulators.
+func (t TraceEntry) String() string {
+	line := make([]byte, 0, 80)
+
+	line = append(line, byteconv.Btoh(byteconv.U16tob(t.PC), 4)...)
+	line = append(line, ": "...)
+	line = append(line, byteconv.Btoh(byteconv.U16tob(uint16(t.Opcode)), 4)...)
+	line = append(line, "  I:"...)
+	line = append(line, byteconv.Btoh(byteconv.U16tob(t.I), 4)...)
+	line = append(line, "  V:"...)
+	for i, v := range t.V {
+		if i > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, byteconv.Btoh([]byte{v}, 2)...)
+	}
+	line = append(line, "  SP:"...)
+	line = append(line, byteconv.Btoh([]byte{t.SP}, 2)...)
+
+	return string(line)
+}
+
+// String summarizes the registers and timers on one line, in the format
+//
+//	PC:0200  I:0000  V:00 01 .. 0F  SP:00  DT:00  ST:00
+//
+// Memory and the display are left out. The format is stable, so it can be
+// used in golden output.
+func (p *Processor) String() string {
+	line := make([]byte, 0, 88)
+
+	line = append(line, "PC:"...)
+	line = append(line, byteconv.Btoh(byteconv.U16tob(p.pc), 4)...)
+	line = append(line, "  I:"...)
+	line = append(line, byteconv.Btoh(byteconv.U16tob(p.i), 4)...)
+	line = append(line, "  V:"...)
+	for i, v := range p.v {
+		if i > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, byteconv.Btoh([]byte{v}, 2)...)
+	}
+	line = append(line, "  SP:"...)
+	line = append(line, byteconv.Btoh([]byte{p.sp}, 2)...)
+	line = append(line, "  DT:"...)
+	line = append(line, byteconv.Btoh([]byte{p.delay}, 2)...)
+	line = append(line, "  ST:"...)
+	line = append(line, byteconv.Btoh([]byte{p.sound}, 2)...)
+
+	return string(line)
+}
+
+// Trace captures the current state and the instruction at the program counter.
+// The opcode is zero if none can be fetched there, such as at the end of
+// memory, where the next Step faults.
+func (p *Processor) Trace() TraceEntry {
+	op, _ := p.OpcodeAtSafe(p.pc)
+	return TraceEntry{
+		PC:     p.pc,
+		Opcode: op,
+		I:      p.i,
+		V:      p.v,
+		SP:     p.sp,
+	}
+}
+
+// WriteTrace writes the line for the instruction about to execute to w, in the
+// format described by TraceEntry.String.
+func (p *Processor) WriteTrace(w io.Writer) error {
+	_, err := io.WriteString(w, p.Trace().String()+"\n")
+	return err
+}
+
+// SetTracer installs tracer to be called from Step with each instruction before
+// it executes: its address, its opcode, and its mnemonic as DisassembleNext
+// formats it, which is DB for words that are not instructions. Unlike the
+// TraceWriter, which records the registers, it shows what a ROM is doing in
+// assembly form. The tracer runs synchronously on the goroutine calling Step.
+// A nil tracer removes it, and then tracing costs nothing.
+func (p *Processor) SetTracer(tracer func(addr uint16, op Opcode, mnemonic string)) {
+	p.tracer = tracer
+}
+
+// RunTrace loads rom into a freshly reset processor and steps it cycles times,
+// returning the state captured before each step. It is meant for comparing runs
+// against golden traces. ROMs that depend on the timers or on random numbers
+// will not produce reproducible traces. The trace stops early, with the entry
+// of the faulting instruction last, if an instruction faults. That includes
+// running off the end of memory, whose entry has a zero opcode. Like Load, it
+// panics if rom does not fit.
+func RunTrace(rom []byte, cycles int) []TraceEntry {
+	var p Processor
+	p.Reset()
+	p.Load(rom)
+
+	trace := make([]TraceEntry, 0, cycles)
+	for range cycles {
+		trace = append(trace, p.Trace())
+		if _, err := p.Step(); err != nil {
+			break
+		}
+	}
+	return trace
+}
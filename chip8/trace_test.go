@@ -0,0 +1,99 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package chip8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunTrace(t *testing.T) {
+	tests := []struct {
+		name   string
+		rom    []byte
+		cycles int
+		want   []TraceEntry
+	}{
+		{
+			name:   "full run",
+			rom:    []byte{0x60, 0x07, 0x12, 0x00}, // LD V0, 7; JP 200
+			cycles: 3,
+			want: []TraceEntry{
+				{PC: 0x200, Opcode: 0x6007},
+				{PC: 0x202, Opcode: 0x1200, V: [RegisterCount]byte{7}},
+				{PC: 0x200, Opcode: 0x6007, V: [RegisterCount]byte{7}},
+			},
+		},
+		{
+			name:   "fault",
+			rom:    []byte{0x60, 0x07, 0x00, 0xEE}, // LD V0, 7; RET
+			cycles: 10,
+			want: []TraceEntry{
+				{PC: 0x200, Opcode: 0x6007},
+				{PC: 0x202, Opcode: 0x00EE, V: [RegisterCount]byte{7}},
+			},
+		},
+		{
+			name:   "runaway past the last address",
+			rom:    []byte{0x1F, 0xFE},
+			cycles: 10,
+			want: []TraceEntry{
+				{PC: 0x200, Opcode: 0x1FFE},
+				{PC: 0xFFE},
+			},
+		},
+		{
+			// The jump lands on the last byte of memory, which holds no
+			// whole opcode.
+			name:   "runaway",
+			rom:    []byte{0x1F, 0xFF},
+			cycles: 10,
+			want: []TraceEntry{
+				{PC: 0x200, Opcode: 0x1FFF},
+				{PC: 0xFFF},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RunTrace(tt.rom, tt.cycles)
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d entries, want %d:\n%v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("entry %d:\n%s\nwant:\n%s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTraceRunaway(t *testing.T) {
+	var p Processor
+	p.Reset()
+	p.WithPC(0xFFF)
+
+	var sb strings.Builder
+	if err := p.WriteTrace(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if want := "0FFF: 0000  "; !strings.HasPrefix(sb.String(), want) {
+		t.Errorf("WriteTrace wrote %q, want it to start %q", sb.String(), want)
+	}
+}
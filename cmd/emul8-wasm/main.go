@@ -0,0 +1,107 @@
+//go:build js && wasm
+
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command emul8-wasm runs the chip8 interpreter in a browser. It exposes a
+// global emul8 object to JavaScript, with the functions:
+//
+//	load(rom: Uint8Array)           reset the processor and load rom
+//	step(n?: number): number|Error  execute n instructions (default 1) and
+//	                                return the info bits they raised, or an
+//	                                Error if the processor faulted
+//	setKey(key: number, down: bool) set whether a key is held down
+//	display(): Uint8Array           the display, one byte per pixel, 64x32
+//	                                or 128x64 in high resolution mode
+//
+// The host is responsible for calling step at the clock rate and redrawing when
+// the chip8.Redraw bit is set.
+package main
+
+import (
+	"emul8/chip8"
+	"syscall/js"
+)
+
+var cpu chip8.Processor
+
+func load(this js.Value, args []js.Value) any {
+	if len(args) < 1 {
+		return js.Global().Get("Error").New("load: missing rom")
+	}
+
+	rom := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(rom, args[0])
+
+	cpu.Reset()
+	if len(rom) > chip8.MaxROMSize {
+		return js.Global().Get("Error").New("load: rom too large")
+	}
+	cpu.Load(rom)
+	return nil
+}
+
+func step(this js.Value, args []js.Value) any {
+	n := 1
+	if len(args) > 0 {
+		n = args[0].Int()
+	}
+
+	var info uint8
+	for range n {
+		i, err := cpu.Step()
+		if err != nil {
+			// Processor faults say where they happened.
+			return js.Global().Get("Error").New(err.Error())
+		}
+		info |= i
+	}
+	return int(info)
+}
+
+func setKey(this js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return js.Global().Get("Error").New("setKey: missing key or state")
+	}
+
+	key := args[0].Int()
+	if key < 0 || key >= chip8.KeyCount {
+		return js.Global().Get("Error").New("setKey: invalid key")
+	}
+	cpu.SetKey(uint8(key), args[1].Truthy())
+	return nil
+}
+
+func display(this js.Value, args []js.Value) any {
+	pixels := cpu.Display()
+	buf := js.Global().Get("Uint8Array").New(len(pixels))
+	js.CopyBytesToJS(buf, pixels)
+	return buf
+}
+
+func main() {
+	cpu.Reset()
+
+	api := js.Global().Get("Object").New()
+	api.Set("load", js.FuncOf(load))
+	api.Set("step", js.FuncOf(step))
+	api.Set("setKey", js.FuncOf(setKey))
+	api.Set("display", js.FuncOf(display))
+	js.Global().Set("emul8", api)
+
+	// Keep the exported functions alive for the lifetime of the page.
+	select {}
+}
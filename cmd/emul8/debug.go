@@ -0,0 +1,288 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"emul8/byteconv"
+	"emul8/chip8"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// continueLimit bounds a single continue command, so a ROM that never reaches
+// a breakpoint hands control back to the prompt.
+const continueLimit int = 1_000_000
+
+const debugHelp = `commands:
+  step [N]          execute N instructions (default 1)
+  continue          run until a breakpoint is reached or a watched register changes
+  break [ADDR]      set a breakpoint at ADDR, or list the breakpoints
+  delete ADDR       remove the breakpoint at ADDR
+  watch X           stop continuing once register VX changes
+  unwatch X         remove the watch on register VX
+  regs              print the registers
+  mem ADDR LEN      print LEN bytes of memory starting at ADDR
+  disasm [ADDR [N]] disassemble N instructions starting at ADDR (default PC, 10)
+  screen            print the display, with # for lit pixels
+  quit              exit the debugger
+Addresses, lengths, and registers are hexadecimal, and counts N are decimal.
+`
+
+type debugger struct {
+	cpu chip8.Processor
+	out io.Writer
+}
+
+func parseHex(s string) (uint16, error) {
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	v, err := strconv.ParseUint(s, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex value %q", s)
+	}
+	return uint16(v), nil
+}
+
+// parseCount parses a number of instructions, which unlike addresses is
+// decimal.
+func parseCount(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+	return n, nil
+}
+
+func hex16(v uint16, n int) string {
+	return byteconv.Btoh(byteconv.U16tob(v), n)
+}
+
+func debug(rom []byte, quirks chip8.Quirks, xochip bool, tracer func(uint16, chip8.Opcode, string), in io.Reader, out io.Writer) error {
+	d := debugger{out: out}
+	d.cpu.Reset()
+	d.cpu.SetQuirks(quirks)
+	d.cpu.XOChip = xochip
+	d.cpu.Logger = slog.Default()
+	d.cpu.SetTracer(tracer)
+	if err := d.cpu.LoadSafe(rom); err != nil {
+		return err
+	}
+
+	fmt.Fprint(out, "type help for a list of commands\n")
+	d.disasm(d.cpu.ProgramCounter(), 1)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "(emul8) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "quit" || fields[0] == "q" {
+			return nil
+		}
+
+		if err := d.exec(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func (d *debugger) exec(cmd string, args []string) (err error) {
+	// Steps return faults such as unknown opcodes, but the other processor
+	// methods panic on them. Report them instead of tearing down the session,
+	// so the state can still be inspected.
+	defer func() {
+		// Processor faults say where they happened, anything else does not.
+		switch r := recover().(type) {
+		case nil:
+		case runtime.Error:
+			err = fmt.Errorf("%w at %s", r, hex16(d.cpu.ProgramCounter(), 3))
+		case error:
+			err = r
+		default:
+			err = fmt.Errorf("%v at %s", r, hex16(d.cpu.ProgramCounter(), 3))
+		}
+	}()
+
+	// Time spent at the prompt must not count against the timers.
+	d.cpu.SyncTimers()
+
+	switch cmd {
+	case "help", "h":
+		fmt.Fprint(d.out, debugHelp)
+	case "step", "s":
+		n := 1
+		if len(args) > 0 {
+			if n, err = parseCount(args[0]); err != nil {
+				return err
+			}
+		}
+		for range n {
+			if _, err := d.cpu.Step(); err != nil {
+				return err
+			}
+		}
+		d.disasm(d.cpu.ProgramCounter(), 1)
+	case "continue", "c":
+		_, err := d.cpu.RunUntilBreak(continueLimit)
+		pc := d.cpu.ProgramCounter()
+		switch {
+		case errors.Is(err, chip8.ErrCycleBudget):
+			fmt.Fprintln(d.out, "no breakpoint reached after", continueLimit, "instructions")
+		case err != nil:
+			return err
+		case slices.Contains(d.cpu.Breakpoints(), pc):
+			fmt.Fprintln(d.out, "breakpoint at", hex16(pc, 3))
+		default:
+			fmt.Fprintln(d.out, "watched register changed")
+			d.regs()
+		}
+		d.disasm(pc, 1)
+	case "break", "b", "delete", "d":
+		if cmd[0] == 'b' && len(args) == 0 {
+			for _, addr := range d.cpu.Breakpoints() {
+				fmt.Fprintln(d.out, hex16(addr, 3))
+			}
+			return nil
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s ADDR", cmd)
+		}
+		addr, err := parseHex(args[0])
+		if err != nil {
+			return err
+		}
+		if cmd[0] == 'b' {
+			d.cpu.AddBreakpoint(addr)
+		} else {
+			d.cpu.RemoveBreakpoint(addr)
+		}
+	case "watch", "w", "unwatch":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: %s X", cmd)
+		}
+		x, err := parseHex(strings.TrimPrefix(strings.ToLower(args[0]), "v"))
+		if err != nil || x > 0xF {
+			return fmt.Errorf("invalid register %q", args[0])
+		}
+		if cmd[0] == 'w' {
+			d.cpu.WatchRegister(uint8(x))
+		} else {
+			d.cpu.UnwatchRegister(uint8(x))
+		}
+	case "regs", "r":
+		d.regs()
+	case "mem", "m":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: mem ADDR LEN")
+		}
+		addr, err := parseHex(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := parseHex(args[1])
+		if err != nil {
+			return err
+		}
+		d.mem(addr, n)
+	case "disasm":
+		addr, n := d.cpu.ProgramCounter(), 10
+		if len(args) > 0 {
+			if addr, err = parseHex(args[0]); err != nil {
+				return err
+			}
+		}
+		if len(args) > 1 {
+			if n, err = parseCount(args[1]); err != nil {
+				return err
+			}
+		}
+		d.disasm(addr, n)
+	case "screen":
+		fmt.Fprintln(d.out, d.cpu.DisplayString())
+	default:
+		return fmt.Errorf("unknown command %q, type help for a list of commands", cmd)
+	}
+	return nil
+}
+
+func (d *debugger) regs() {
+	fmt.Fprintf(d.out, "PC: %s  I: %s  Stack: %d  DT: %s  ST: %s\n",
+		hex16(d.cpu.ProgramCounter(), 3), hex16(d.cpu.Index(), 3), d.cpu.StackDepth(),
+		hex16(uint16(d.cpu.Delay()), 2), hex16(uint16(d.cpu.Sound()), 2))
+
+	for i := range uint8(chip8.RegisterCount) {
+		sep := " "
+		if i%8 == 7 {
+			sep = "\n"
+		}
+		fmt.Fprintf(d.out, "V%s: %s%s", hex16(uint16(i), 1), hex16(uint16(d.cpu.Register(i)), 2), sep)
+	}
+
+	if !d.cpu.FontIntact() {
+		fmt.Fprintln(d.out, "warning: the font has been overwritten")
+	}
+}
+
+func (d *debugger) mem(addr, n uint16) {
+	data := make([]byte, n)
+	data = data[:d.cpu.Read(addr, data)]
+
+	for off := 0; off < len(data); off += 16 {
+		row := data[off:min(off+16, len(data))]
+		fmt.Fprintf(d.out, "%s:", hex16(addr+uint16(off), 3))
+		for _, b := range row {
+			fmt.Fprintf(d.out, " %s", byteconv.Btoh([]byte{b}, 2))
+		}
+		fmt.Fprintln(d.out)
+	}
+}
+
+func (d *debugger) disasm(addr uint16, n int) {
+	for range n {
+		op, ok := d.cpu.PeekOpcodeAt(addr)
+		if !ok {
+			return
+		}
+		buf := byteconv.U16tob(uint16(op))
+
+		str, ok := op.Mnemonic()
+		if !ok {
+			str = "DB 0x" + byteconv.Btoh(buf, 4)
+		}
+
+		marker := "  "
+		if addr == d.cpu.ProgramCounter() {
+			marker = "=>"
+		}
+		fmt.Fprintf(d.out, "%s %s: %s  %s\n", marker, hex16(addr, 3), byteconv.Btoh(buf, 4), str)
+		addr += 2
+	}
+}
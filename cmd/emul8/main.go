@@ -18,29 +18,237 @@ package main
 
 import (
 	"emul8"
+	"emul8/chip8"
+	"emul8/romfile"
+	"emul8/server"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
 	"io"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+var quirkPresets = map[string]chip8.Quirks{
+	"default": {},
+	"chip8":   chip8.ProfileVIP.Quirks(),
+	"schip":   chip8.ProfileSCHIP.Quirks(),
+	"xochip":  chip8.ProfileXOCHIP.Quirks(),
+}
+
+var scaleModes = map[string]emul8.ScaleMode{
+	"pixels":  emul8.ScalePixels,
+	"smooth":  emul8.ScaleSmooth,
+	"integer": emul8.ScaleInteger,
+}
+
+func parseColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return nil, errors.New("color must be six hex digits, like 00FF00")
+	}
+
+	rgb, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// traceTo returns a tracer that writes each instruction to w as a line of the
+// -disasm listing, so that the traces of two runs can be diffed.
+func traceTo(w io.Writer) func(addr uint16, op chip8.Opcode, mnemonic string) {
+	return func(addr uint16, op chip8.Opcode, mnemonic string) {
+		fmt.Fprintf(w, "%s: %s  %s\n", hex16(addr, 3), hex16(uint16(op), 4), mnemonic)
+	}
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("must specify file")
+	var (
+		clock    = flag.Int("clock", 700, "instructions executed per `second`")
+		timer    = flag.Int("timer", 60, "delay and sound timer ticks per `second`")
+		cycles   = flag.Bool("cycles", false, "pace instructions by their cost on the COSMAC VIP instead of -clock")
+		quirks   = flag.String("quirks", "default", "quirks `preset` to emulate: default, chip8, schip, or xochip")
+		qfile    = flag.String("quirks-file", "", "read quirks from a JSON `file`, applied over the preset")
+		scale    = flag.Int("scale", 10, "window pixels per display `pixel`")
+		fgColor  = flag.String("fg", "00FF00", "`color` of lit pixels, as RRGGBB hex")
+		bgColor  = flag.String("bg", "000000", "`color` of unlit pixels, as RRGGBB hex")
+		filter   = flag.String("filter", "pixels", "display scaling `filter`: pixels, smooth, or integer")
+		invert   = flag.Bool("invert", false, "swap the colors of lit and unlit pixels")
+		budget   = flag.Uint64("max", 0, "stop after executing `n` instructions, 0 for no limit")
+		mute     = flag.Bool("mute", false, "disable sound")
+		envelope = flag.Duration("envelope", emul8.DefaultEnvelope, "buzzer fade in and out `duration`, negative for none")
+		swap     = flag.Bool("swap", false, "byte-swap every 16-bit word of a little-endian rom")
+		validate = flag.Bool("validate", false, "list words of the rom that are not known instructions, then exit")
+		disasm   = flag.Bool("disasm", false, "print a listing of every word of the rom, then exit")
+		debugger = flag.Bool("debug", false, "run headless in an interactive debugger on stdin")
+		terminal = flag.Bool("tui", false, "run in the terminal instead of a window")
+		serve    = flag.String("serve", "", "run headless, serving an HTTP control API on `addr`")
+		verbose  = flag.Bool("v", false, "log diagnostic messages about ROM behavior")
+		trace    = flag.Bool("trace", false, "log every executed instruction to stderr, in the -disasm layout")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] rom\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	slog.SetDefault(logger)
+
+	if *clock <= 0 {
+		fatal("clock must be positive")
+	}
+
+	if *timer <= 0 {
+		fatal("timer rate must be positive")
 	}
-	name := os.Args[1]
 
-	var e emul8.Emulator
+	if *scale <= 0 {
+		fatal("scale must be positive")
+	}
 
-	f, err := os.Open(name)
+	q, ok := quirkPresets[*quirks]
+	if !ok {
+		fatal("unknown quirks preset", "preset", *quirks)
+	}
+
+	preset := *quirks
+	if *qfile != "" {
+		var err error
+		if q, preset, err = loadQuirks(*qfile, *quirks); err != nil {
+			fatal("invalid quirks file", "error", err)
+		}
+	}
+
+	// XO-CHIP's display planes are a mode of the processor rather than a
+	// quirk, since classic ROMs must not see them. A quirks file may select
+	// the preset as well as the flag.
+	xochip := preset == "xochip"
+
+	scaleMode, ok := scaleModes[*filter]
+	if !ok {
+		fatal("unknown scaling filter", "filter", *filter)
+	}
+
+	fg, err := parseColor(*fgColor)
+	if err != nil {
+		fatal("invalid foreground color", "error", err)
+	}
+
+	bg, err := parseColor(*bgColor)
 	if err != nil {
-		log.Fatal(err)
+		fatal("invalid background color", "error", err)
 	}
 
-	b, err := io.ReadAll(f)
+	var tracer func(uint16, chip8.Opcode, string)
+	if *trace {
+		tracer = traceTo(os.Stderr)
+	}
+
+	e := emul8.Emulator{
+		ClockRate:       time.Second / time.Duration(*clock),
+		TimerRate:       time.Second / time.Duration(*timer),
+		Quirks:          q,
+		Scale:           *scale,
+		ScaleMode:       scaleMode,
+		Palette:         emul8.Palette{bg, fg},
+		Invert:          *invert,
+		Mute:            *mute,
+		BeepEnvelope:    *envelope,
+		Logger:          logger,
+		MaxInstructions: *budget,
+		SaveFile:        name + ".state",
+		FlagsFile:       name + ".flags",
+		XOChip:          xochip,
+		Tracer:          tracer,
+	}
+
+	if *cycles {
+		e.CycleCosts = chip8.VIPCosts()
+	}
+
+	b, err := romfile.Load(name)
 	if err != nil {
-		log.Fatal(err)
+		fatal("cannot load rom", "error", err)
 	}
 
-	e.Load(b)
-	e.Run()
+	if *swap {
+		b = chip8.SwapROM(b)
+	}
+
+	if *validate {
+		errs := chip8.Validate(b)
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *disasm {
+		for _, in := range chip8.Disassemble(b, chip8.ProgramStartAddress) {
+			fmt.Printf("%s: %s  %s\n", hex16(in.Address, 3), hex16(uint16(in.Opcode), 4), in)
+		}
+		return
+	}
+
+	if *debugger {
+		if err := debug(b, q, xochip, tracer, os.Stdin, os.Stdout); err != nil {
+			fatal("debugger failed", "error", err)
+		}
+		return
+	}
+
+	if *terminal {
+		if err := tui(b, q, xochip, tracer, e.ClockRate, e.TimerRate, os.Stdin, os.Stdout); err != nil {
+			fatal("emulator stopped", "error", err)
+		}
+		return
+	}
+
+	if *serve != "" {
+		s := server.New()
+		s.MaxInstructions = *budget
+		s.SetQuirks(q)
+		s.SetXOChip(xochip)
+		if err := s.Load(b); err != nil {
+			fatal("cannot load rom", "error", err)
+		}
+		logger.Info("serving control API", "addr", *serve)
+		if err := http.ListenAndServe(*serve, s); err != nil {
+			fatal("server failed", "error", err)
+		}
+		return
+	}
+
+	if err := e.Load(b); err != nil {
+		fatal("cannot load rom", "error", err)
+	}
+	if err := e.Run(); err != nil {
+		fatal("emulator stopped", "error", err)
+	}
 }
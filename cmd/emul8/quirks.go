@@ -0,0 +1,81 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"emul8/chip8"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// quirksFile is the layout of a -quirks-file. Preset names an entry of
+// quirkPresets to start from, instead of the -quirks flag, and the fields of
+// chip8.Quirks that are present override it:
+//
+//	{"preset": "schip", "WrapSprites": true, "WrapCollision": 1}
+type quirksFile struct {
+	Preset string `json:"preset"`
+	chip8.Quirks
+}
+
+// loadQuirks reads a quirks file, applying it over the preset named base, and
+// returns the quirks along with the name of the preset they start from, which
+// the file may change. It rejects unknown fields and values out of range, so a
+// typo cannot silently leave a quirk at its default.
+func loadQuirks(path, base string) (chip8.Quirks, string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return chip8.Quirks{}, "", err
+	}
+
+	decode := func(v any) error {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return fmt.Errorf("%s: unexpected data after the quirks object", path)
+		}
+		return nil
+	}
+
+	// The preset is read first, because the rest of the file is applied on
+	// top of it.
+	f := quirksFile{Preset: base}
+	if err := decode(&f); err != nil {
+		return chip8.Quirks{}, "", err
+	}
+
+	preset, ok := quirkPresets[f.Preset]
+	if !ok {
+		return chip8.Quirks{}, "", fmt.Errorf("%s: unknown preset %q", path, f.Preset)
+	}
+
+	f.Quirks = preset
+	if err := decode(&f); err != nil {
+		return chip8.Quirks{}, "", err
+	}
+
+	if f.WrapCollision > chip8.WrapCollisionIgnore {
+		return chip8.Quirks{}, "", fmt.Errorf("%s: WrapCollision must be 0 (count) or 1 (ignore)", path)
+	}
+	return f.Quirks, f.Preset, nil
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"emul8/chip8"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// terminalKeys are the keys that stand in for chip8.Keypad in the terminal, in
+// the same positions on a QWERTY keyboard as in the window.
+var terminalKeys = [4][4]byte{
+	{'1', '2', '3', '4'},
+	{'q', 'w', 'e', 'r'},
+	{'a', 's', 'd', 'f'},
+	{'z', 'x', 'c', 'v'},
+}
+
+var terminalKeyMap = func() map[byte]uint8 {
+	m := make(map[byte]uint8)
+	for r, row := range terminalKeys {
+		for c, b := range row {
+			m[b] = chip8.Keypad[r][c]
+			if b >= 'a' && b <= 'z' {
+				m[b-'a'+'A'] = chip8.Keypad[r][c] // With caps lock on.
+			}
+		}
+	}
+	return m
+}()
+
+// keyHold is how long a key stays held after the terminal last reported it.
+// Terminals report presses but not releases, so a key is released once it
+// stops repeating. It is longer than the usual delay before a held key starts
+// to repeat.
+const keyHold = 600 * time.Millisecond
+
+// Control characters that end the session, since raw mode turns them into
+// input rather than signals.
+const (
+	keyInterrupt byte = 0x03 // Ctrl-C
+	keyEOF       byte = 0x04 // Ctrl-D
+)
+
+// screen draws the display to a terminal with block characters, one character
+// per pixel, repositioning the cursor rather than scrolling between frames.
+type screen struct {
+	out   io.Writer
+	width int // Of the last frame drawn, or zero before the first.
+	buf   strings.Builder
+}
+
+// draw writes a frame of display, which is width pixels wide.
+func (s *screen) draw(display []byte, width int) error {
+	s.buf.Reset()
+	if width != s.width {
+		// A smaller frame would leave part of the previous one behind.
+		s.buf.WriteString("\x1b[2J")
+		s.width = width
+	}
+	s.buf.WriteString("\x1b[H")
+
+	for row := 0; row < len(display); row += width {
+		for _, val := range display[row : row+width] {
+			if val != 0 {
+				s.buf.WriteString("█")
+			} else {
+				s.buf.WriteByte(' ')
+			}
+		}
+		// Raw mode does not translate a newline into a carriage return.
+		s.buf.WriteString("\r\n")
+	}
+
+	_, err := io.WriteString(s.out, s.buf.String())
+	return err
+}
+
+// tui runs rom in the terminal, drawing the display to out and reading keys
+// from in, which must be a terminal. It returns once Ctrl-C or Ctrl-D is
+// pressed, or with an error when the ROM faults.
+func tui(rom []byte, quirks chip8.Quirks, xochip bool, tracer func(uint16, chip8.Opcode, string), clockRate, timerRate time.Duration, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("input is not a terminal")
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// Hide the cursor while running, and leave the last frame on screen with
+	// the prompt below it.
+	io.WriteString(out, "\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h")
+
+	var cpu chip8.Processor
+	cpu.Reset()
+	cpu.SetQuirks(quirks)
+	cpu.XOChip = xochip
+	cpu.SetClockRate(clockRate)
+	cpu.SetTimerRate(timerRate)
+	cpu.SetTracer(tracer)
+	if err := cpu.LoadSafe(rom); err != nil {
+		return err
+	}
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			for _, b := range buf[:n] {
+				keys <- b
+			}
+			if err != nil {
+				close(keys)
+				return
+			}
+		}
+	}()
+
+	s := screen{out: out}
+	w, _ := cpu.Dimensions()
+	if err := s.draw(cpu.Display(), w); err != nil {
+		return err
+	}
+
+	var held [chip8.KeyCount]time.Time
+	ticker := time.NewTicker(cpu.ClockRate())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case b, ok := <-keys:
+			if !ok || b == keyInterrupt || b == keyEOF {
+				return nil
+			}
+			if key, ok := terminalKeyMap[b]; ok {
+				held[key] = time.Now()
+				cpu.SetKey(key, true)
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		for key, at := range held {
+			if !at.IsZero() && now.Sub(at) >= keyHold {
+				held[key] = time.Time{}
+				cpu.SetKey(uint8(key), false)
+			}
+		}
+
+		info, err := cpu.Step()
+		if err != nil {
+			return err
+		}
+
+		// Only redraw when the display changed, which keeps the terminal from
+		// flickering.
+		if info&chip8.Redraw != 0 {
+			w, _ := cpu.Dimensions()
+			if err := s.draw(cpu.Display(), w); err != nil {
+				return err
+			}
+		}
+	}
+}
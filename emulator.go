@@ -17,11 +17,17 @@
 package emul8
 
 import (
+	"bytes"
 	"context"
 	"emul8/byteconv"
 	"emul8/chip8"
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
+	"io/fs"
+	"log/slog"
+	"os"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -37,33 +43,219 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-var keyMap = map[fyne.KeyName]uint8{
-	fyne.Key1: 0x1, fyne.Key2: 0x2, fyne.Key3: 0x3, fyne.Key4: 0xC,
-	fyne.KeyQ: 0x4, fyne.KeyW: 0x5, fyne.KeyE: 0x6, fyne.KeyR: 0xD,
-	fyne.KeyA: 0x7, fyne.KeyS: 0x8, fyne.KeyD: 0x9, fyne.KeyF: 0xE,
-	fyne.KeyZ: 0xA, fyne.KeyX: 0x0, fyne.KeyC: 0xB, fyne.KeyV: 0xF,
+// hostKeys are the keys that stand in for chip8.Keypad, in the same positions
+// on a QWERTY keyboard.
+var hostKeys = [4][4]fyne.KeyName{
+	{fyne.Key1, fyne.Key2, fyne.Key3, fyne.Key4},
+	{fyne.KeyQ, fyne.KeyW, fyne.KeyE, fyne.KeyR},
+	{fyne.KeyA, fyne.KeyS, fyne.KeyD, fyne.KeyF},
+	{fyne.KeyZ, fyne.KeyX, fyne.KeyC, fyne.KeyV},
 }
 
+var keyMap = func() map[fyne.KeyName]uint8 {
+	m := make(map[fyne.KeyName]uint8)
+	for r, row := range hostKeys {
+		for c, name := range row {
+			m[name] = chip8.Keypad[r][c]
+		}
+	}
+	return m
+}()
+
 var cpu chip8.Processor
 
 func init() {
 	cpu.Reset()
 }
 
+const (
+	defaultScale int = 10
+)
+
+// DefaultPalette is green on black for classic ROMs, with amber and white for
+// the additional colors of XO-CHIP's second bitplane.
+var DefaultPalette = Palette{
+	color.RGBA{R: 0, G: 0, B: 0, A: 255},
+	color.RGBA{R: 0, G: 255, B: 0, A: 255},
+	color.RGBA{R: 255, G: 170, B: 0, A: 255},
+	color.RGBA{R: 255, G: 255, B: 255, A: 255},
+}
+
+// Palette maps display pixel values to colors. A pixel value holds one bit per
+// bitplane, so the first plane selects index 1 and the second plane index 2.
+// Classic CHIP-8 only draws to the first plane, using indices 0 and 1.
+type Palette [4]color.Color
+
+// ScaleMode selects how the display is scaled up to the size of the window.
+type ScaleMode uint8
+
+const (
+	// ScalePixels scales by nearest neighbor, keeping the crisp "pixelated"
+	// retro look.
+	ScalePixels ScaleMode = iota
+
+	// ScaleSmooth scales with bilinear filtering, which softens the edges of
+	// moving sprites.
+	ScaleSmooth
+
+	// ScaleInteger renders the display at Scale times its resolution before
+	// handing it to the window, so every pixel is exactly Scale by Scale window
+	// pixels regardless of how the graphics driver filters.
+	ScaleInteger
+)
+
 type Emulator struct {
+	// ClockRate is the interval between instructions. Zero means chip8.ClockRate.
+	// SetClockRate changes it while running.
+	ClockRate time.Duration
+
+	// TimerRate is the interval between ticks of the delay and sound timers.
+	// Zero means chip8.TimerRate.
+	TimerRate time.Duration
+
+	// CycleCosts, when set, paces execution by the cost of each instruction in
+	// VIP machine cycles, such as chip8.VIPCosts, instead of running every
+	// instruction at ClockRate.
+	CycleCosts chip8.CostTable
+
+	// Quirks are applied to the processor when Run starts.
+	Quirks chip8.Quirks
+
+	// XOChip runs the processor in XO-CHIP mode, with its two display planes
+	// shown in the four colors of the Palette. See chip8.Processor.XOChip.
+	XOChip bool
+
+	// Rand, when set, is the source of the random numbers of CXNN, for
+	// instance a seeded generator to make a run reproducible. Nil means the
+	// global source of math/rand/v2.
+	Rand chip8.RandSource
+
+	// Scale is the number of window pixels per display pixel. Zero means 10.
+	Scale int
+
+	// ScaleMode selects how the display is scaled to the window.
+	ScaleMode ScaleMode
+
+	// Palette holds the colors of the display. Nil entries fall back to
+	// DefaultPalette.
+	Palette Palette
+
+	// Invert swaps the colors of lit and unlit pixels.
+	Invert bool
+
+	// Mute disables the sound timer buzzer.
+	Mute bool
+
+	// BeepEnvelope is the attack and release time of the buzzer, as described
+	// by Beep. Zero means DefaultEnvelope.
+	BeepEnvelope time.Duration
+
+	// Logger, when set, receives warnings from the emulator and the processor.
+	Logger *slog.Logger
+
+	// Tracer, when set, is installed with chip8.Processor.SetTracer when Run
+	// starts, to see each instruction the ROM executes. It runs in the CPU
+	// loop, so it must be quick.
+	Tracer func(addr uint16, op chip8.Opcode, mnemonic string)
+
+	// SaveFile is the quick-save slot written by SaveKey and read back by
+	// LoadKey, typically next to the ROM. Empty disables both keys.
+	SaveFile string
+
+	// FlagsFile keeps the RPL user flags that SUPER-CHIP games save with FX75,
+	// often their high scores, across runs. It is read when Run starts and
+	// written each time the flags are saved. Empty keeps them in memory only.
+	FlagsFile string
+
+	// SaveKey and LoadKey save the machine state to SaveFile and restore it
+	// from there. They must not be keys mapped to the CHIP-8 keypad. Empty
+	// means F5 and F9.
+	SaveKey fyne.KeyName
+	LoadKey fyne.KeyName
+
+	// MaxInstructions, when nonzero, stops Run with an error wrapping
+	// chip8.ErrCycleBudget once that many instructions have been executed, so
+	// a ROM stuck in a loop cannot run forever. Resets do not refill it.
+	MaxInstructions uint64
+
+	// ResetKey restarts the loaded ROM. It must not be one of the keys mapped
+	// to the CHIP-8 keypad. Empty means F2.
+	ResetKey fyne.KeyName
+
+	// KeypadKey shows or hides an overlay of the CHIP-8 keypad, with the keys
+	// that stand in for it and the keys that are held. Empty means F1.
+	KeypadKey fyne.KeyName
+
+	// ScreenshotKey writes the display to a PNG named after the current time
+	// in the working directory, in the window's colors and scaled by Scale.
+	// Empty means F12.
+	ScreenshotKey fyne.KeyName
+
+	// RecordKey starts recording the display to an animated GIF named after
+	// the current time in the working directory, and stops and writes it when
+	// pressed again. The recording is in the window's colors and scaled by
+	// Scale. Empty means F10.
+	RecordKey fyne.KeyName
+
+	rom     []byte
 	beep    Beep
+	keypad  *keypadOverlay
+	reset   atomic.Bool
+	save    atomic.Bool
+	restore atomic.Bool
+	shot    atomic.Bool
+	record  atomic.Bool
+	skipped atomic.Uint64
+	hz      atomic.Uint64
 	paused  atomic.Bool
 	next    atomic.Bool
+	frame   atomic.Bool
 	running atomic.Bool
+	rate    atomic.Int64 // Interval set by SetClockRate.
+	newRate atomic.Bool  // Whether the loop has yet to apply rate.
+	loop    sync.WaitGroup
+
+	mu     sync.Mutex
+	app    fyne.App // The running app, guarded by mu.
+	closed bool     // Guarded by mu.
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 func (e *Emulator) onKeyDown(k *fyne.KeyEvent) {
 	if hex, ok := keyMap[k.Name]; ok {
 		cpu.SetKey(hex, true)
+		e.keypad.Refresh(cpu.KeyMask())
 	}
 }
 
 func (e *Emulator) onKeyUp(k *fyne.KeyEvent) {
+	if k.Name == e.resetKey() {
+		e.reset.Store(true)
+		return
+	}
+
+	if e.SaveFile != "" && k.Name == e.saveKey() {
+		e.save.Store(true)
+		return
+	}
+
+	if e.SaveFile != "" && k.Name == e.loadKey() {
+		e.restore.Store(true)
+		return
+	}
+
+	if k.Name == e.screenshotKey() {
+		e.shot.Store(true)
+		return
+	}
+
+	if k.Name == e.recordKey() {
+		e.record.Store(true)
+		return
+	}
+
 	if k.Name == fyne.KeyP {
 		e.paused.Store(!e.paused.Load())
 		return
@@ -74,14 +266,42 @@ func (e *Emulator) onKeyUp(k *fyne.KeyEvent) {
 		return
 	}
 
+	if k.Name == fyne.KeyM {
+		e.paused.Store(true)
+		e.frame.Store(true)
+		return
+	}
+
+	if k.Name == e.keypadKey() {
+		e.keypad.Toggle(cpu.KeyMask())
+		return
+	}
+
 	if hex, ok := keyMap[k.Name]; ok {
 		cpu.SetKey(hex, false)
+		e.keypad.Refresh(cpu.KeyMask())
 	}
 }
 
-func (e *Emulator) Load(b []byte) {
+// mnemonic is the short form of op shown in the opcode console.
+func mnemonic(op chip8.Opcode) string {
+	if str, ok := op.Mnemonic(); ok {
+		return str
+	}
+	return op.String()
+}
+
+// Load resets the processor and loads b, returning an error wrapping
+// chip8.ErrROMTooLarge if it does not fit.
+func (e *Emulator) Load(b []byte) error {
+	rom := bytes.Clone(b)
+	cpu.XOChip = e.XOChip // Decides how large a ROM fits.
 	cpu.Reset()
-	cpu.Load(b)
+	if err := cpu.LoadSafe(rom); err != nil {
+		return err
+	}
+	e.rom = rom
+	return nil
 }
 
 type datum struct {
@@ -174,41 +394,318 @@ func (c *Content) MinSize() fyne.Size {
 	return c.size
 }
 
-func (e *Emulator) Run() {
+// SetClockRate changes the interval between instructions of a running
+// emulator, for instance to speed through a ROM that crunches numbers or to
+// slow one down for debugging. Zero or less restores chip8.ClockRate. It may be
+// called from any goroutine, and takes effect from the next instruction.
+func (e *Emulator) SetClockRate(d time.Duration) {
+	e.rate.Store(int64(d))
+	e.newRate.Store(true)
+}
+
+// stepsPerFrame is the number of instructions executed in one timer tick.
+func (e *Emulator) stepsPerFrame() int {
+	return max(int(cpu.TimerRate()/cpu.ClockRate()), 1)
+}
+
+func (e *Emulator) scale() float32 {
+	if e.Scale > 0 {
+		return float32(e.Scale)
+	}
+	return float32(defaultScale)
+}
+
+// color looks up the color of a pixel value.
+func (e *Emulator) color(val byte) color.Color {
+	if e.Invert {
+		val ^= 0x1
+	}
+
+	if c := e.Palette[val&0x3]; c != nil {
+		return c
+	}
+	return DefaultPalette[val&0x3]
+}
+
+// colors returns the colors of every pixel value, for chip8.Processor.Palette.
+func (e *Emulator) colors() color.Palette {
+	var palette color.Palette
+	for val := range byte(len(e.Palette)) {
+		palette = append(palette, e.color(val))
+	}
+	return palette
+}
+
+func (e *Emulator) resetKey() fyne.KeyName {
+	if e.ResetKey != "" {
+		return e.ResetKey
+	}
+	return fyne.KeyF2
+}
+
+func (e *Emulator) warn(msg string, args ...any) {
+	if e.Logger != nil {
+		e.Logger.Warn(msg, args...)
+	}
+}
+
+func (e *Emulator) saveKey() fyne.KeyName {
+	if e.SaveKey != "" {
+		return e.SaveKey
+	}
+	return fyne.KeyF5
+}
+
+func (e *Emulator) keypadKey() fyne.KeyName {
+	if e.KeypadKey != "" {
+		return e.KeypadKey
+	}
+	return fyne.KeyF1
+}
+
+func (e *Emulator) screenshotKey() fyne.KeyName {
+	if e.ScreenshotKey != "" {
+		return e.ScreenshotKey
+	}
+	return fyne.KeyF12
+}
+
+func (e *Emulator) recordKey() fyne.KeyName {
+	if e.RecordKey != "" {
+		return e.RecordKey
+	}
+	return fyne.KeyF10
+}
+
+func (e *Emulator) loadKey() fyne.KeyName {
+	if e.LoadKey != "" {
+		return e.LoadKey
+	}
+	return fyne.KeyF9
+}
+
+// saveState writes the machine state to SaveFile.
+func (e *Emulator) saveState() error {
+	b, err := cpu.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.SaveFile, b, 0o644)
+}
+
+// stopRecording writes rec, logging the outcome.
+func (e *Emulator) stopRecording(rec *recording) {
+	name, err := rec.stop()
+	if err != nil {
+		e.warn("cannot write recording", "file", name, "error", err)
+	} else if e.Logger != nil {
+		e.Logger.Info("wrote recording", "file", name)
+	}
+}
+
+// screenshot writes the display to a PNG in the working directory, and returns
+// its name.
+func (e *Emulator) screenshot() (string, error) {
+	name := time.Now().Format("emul8-20060102-150405.png")
+
+	f, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cpu.WritePNG(f, int(e.scale())); err != nil {
+		f.Close()
+		return "", err
+	}
+	return name, f.Close()
+}
+
+// recording is a GIF recording of the display in progress.
+type recording struct {
+	file *os.File
+	gif  *chip8.Recorder
+}
+
+// startRecording starts recording the display to a GIF in the working
+// directory, from the current frame.
+func (e *Emulator) startRecording() (*recording, error) {
+	f, err := os.Create(time.Now().Format("emul8-20060102-150405.gif"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &recording{file: f, gif: chip8.NewRecorder(f, int(e.scale()))}
+	r.gif.Sample(&cpu, chip8.Redraw, time.Now())
+	return r, nil
+}
+
+// stop writes the recording, and returns the name of its file.
+func (r *recording) stop() (string, error) {
+	err := r.gif.Close()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return r.file.Name(), err
+}
+
+// loadState restores the machine from SaveFile. It reports false, without an
+// error, if nothing has been saved yet.
+func (e *Emulator) loadState() (bool, error) {
+	b, err := os.ReadFile(e.SaveFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := cpu.UnmarshalBinary(b); err != nil {
+		return false, fmt.Errorf("%s: %w", e.SaveFile, err)
+	}
+
+	// The keys held when the state was saved are not the ones held now.
+	cpu.SetKeyMask(0)
+	return true, nil
+}
+
+// loadFlags restores the RPL user flags from FlagsFile, if it exists.
+func (e *Emulator) loadFlags() error {
+	b, err := os.ReadFile(e.FlagsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var flags [chip8.FlagCount]byte
+	if len(b) != len(flags) {
+		return fmt.Errorf("%s: %d bytes, want %d", e.FlagsFile, len(b), len(flags))
+	}
+	copy(flags[:], b)
+	cpu.SetFlags(flags)
+	return nil
+}
+
+// saveFlags writes the RPL user flags to FlagsFile. It is the processor's
+// flags hook, so it runs in the CPU loop, and reports failures rather than
+// stopping the ROM.
+func (e *Emulator) saveFlags(flags [chip8.FlagCount]byte) {
+	if err := os.WriteFile(e.FlagsFile, flags[:], 0o644); err != nil {
+		e.warn("cannot save flags", "error", err)
+	}
+}
+
+// SkippedRefreshes reports how many display updates were folded into a later
+// refresh of the window, because they happened within the same frame.
+func (e *Emulator) SkippedRefreshes() uint64 {
+	return e.skipped.Load()
+}
+
+// MeasuredHz reports how many instructions were executed during the last full
+// second of running. Falling well short of the clock rate means the host cannot
+// keep up, for instance because drawing or audio is too slow.
+func (e *Emulator) MeasuredHz() uint64 {
+	return e.hz.Load()
+}
+
+// newBuffer allocates a back-buffer for a display of w by h pixels. In integer
+// mode it is larger than the display by a whole factor, so that the window
+// shows each pixel as a block of the same size at either resolution.
+func (e *Emulator) newBuffer(w, h int) *image.RGBA {
+	factor := 1
+	if e.ScaleMode == ScaleInteger {
+		factor = max(int(e.scale())*chip8.Width/w, 1)
+	}
+	return image.NewRGBA(image.Rect(0, 0, w*factor, h*factor))
+}
+
+// paint renders a copy of the display, w pixels wide, into the window's
+// back-buffer. It must only be called from the fyne main goroutine, which also
+// reads the buffer.
+func (e *Emulator) paint(buffer *image.RGBA, frame []byte, w int) {
+	// Each pixel is painted as a block as large as the buffer is larger.
+	factor := buffer.Bounds().Dx() / w
+
+	for i, val := range frame {
+		x, y := i%w, i/w
+		c := e.color(val)
+
+		for dy := range factor {
+			for dx := range factor {
+				buffer.Set(x*factor+dx, y*factor+dy, c) // Directly sets pixels in the buffer
+			}
+		}
+	}
+}
+
+// Run opens the emulator window and executes the loaded ROM until the window
+// is closed, the instruction budget runs out, or Close is called. It closes the
+// emulator before returning.
+func (e *Emulator) Run() error {
+	cpu.SetQuirks(e.Quirks)
+	cpu.Logger = e.Logger
+	cpu.SetTracer(e.Tracer)
+	cpu.Costs = e.CycleCosts
+	cpu.Rand = e.Rand
+	cpu.XOChip = e.XOChip
+	cpu.Palette = e.colors()
+	cpu.SetClockRate(e.ClockRate)
+	cpu.SetTimerRate(e.TimerRate)
+	if e.FlagsFile != "" {
+		if err := e.loadFlags(); err != nil {
+			e.warn("cannot load flags", "error", err)
+		}
+		cpu.SetFlagsHook(e.saveFlags)
+	}
+	e.beep.Attack, e.beep.Release = e.BeepEnvelope, e.BeepEnvelope
+
 	a := app.New()
 	w := a.NewWindow("Chip-8 Emulator")
 
-	// Create a back-buffer for the pixel data
-	buffer := image.NewRGBA(image.Rect(0, 0, chip8.Width, chip8.Height))
+	// Create a back-buffer for the pixel data. It is replaced whenever the ROM
+	// switches resolution.
+	bufferWidth, bufferHeight := cpu.Dimensions()
+	buffer := e.newBuffer(bufferWidth, bufferHeight)
 
 	image := canvas.NewImageFromImage(buffer)
 	image.FillMode = canvas.ImageFillStretch  // Scales the grid to window size
 	image.ScaleMode = canvas.ImageScalePixels // Maintains "pixelated" retro look
+	if e.ScaleMode == ScaleSmooth {
+		image.ScaleMode = canvas.ImageScaleSmooth
+	}
 
 	canv, ok := w.Canvas().(desktop.Canvas) // Extension that exposes OnKeyUp event
 	if !ok {
 		panic("emulator cannot be run on mobile")
 	}
+	e.keypad = newKeypadOverlay()
 	canv.SetOnKeyDown(e.onKeyDown)
 	canv.SetOnKeyUp(e.onKeyUp)
 
+	// Key-up events are not delivered while the window is in the background,
+	// so release everything rather than leave keys held down.
+	a.Lifecycle().SetOnExitedForeground(func() {
+		cpu.ReleaseKeys()
+		e.keypad.Refresh(0)
+	})
+
 	imageContent := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(float32(chip8.Width)*10, float32(chip8.Height)*10)),
-		image,
+		layout.NewGridWrapLayout(fyne.NewSize(float32(chip8.Width)*e.scale(), float32(chip8.Height)*e.scale())),
+		container.NewStack(image, e.keypad.Object()),
 	)
 
-	backgroundColor := color.RGBA{R: 0, G: 0, B: 0, A: 255}
-	background := canvas.NewRectangle(backgroundColor)
+	background := canvas.NewRectangle(e.color(0))
 
 	opcodeData := NewConsole(22, layout.NewVBoxLayout())
 	opcodeContent := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(125, (float32)(chip8.Height)*10)),
+		layout.NewGridWrapLayout(fyne.NewSize(125, (float32)(chip8.Height)*e.scale())),
 		opcodeData.Object(),
 	)
 
 	registerData := NewConsole(chip8.RegisterCount, layout.NewGridLayoutWithColumns(4))
 	registerContent := container.New(
-		layout.NewGridWrapLayout(fyne.NewSize(float32(chip8.Width)*10, 200)),
+		layout.NewGridWrapLayout(fyne.NewSize(float32(chip8.Width)*e.scale(), 200)),
 		registerData.Object(),
 	)
 	registerContent = container.New(
@@ -236,6 +733,10 @@ func (e *Emulator) Run() {
 		widget.NewToolbarAction(theme.MediaSkipNextIcon(), func() {
 			e.next.Store(true)
 		}),
+		widget.NewToolbarAction(theme.MediaFastForwardIcon(), func() {
+			e.paused.Store(true)
+			e.frame.Store(true)
+		}),
 	)
 
 	b := byteconv.U16tob(cpu.ProgramCounter())
@@ -256,8 +757,9 @@ func (e *Emulator) Run() {
 
 	cpuData.Refresh()
 
-	opcode := cpu.OpcodeAt(cpu.ProgramCounter())
-	opcodeData.Prepend(opcode.String())
+	if opcode, ok := cpu.PeekOpcode(); ok {
+		opcodeData.Prepend(mnemonic(opcode))
+	}
 	opcodeData.Refresh()
 
 	box := container.NewBorder(toolbar, registerContent, opcodeContent, cpuContent, imageContent)
@@ -268,31 +770,165 @@ func (e *Emulator) Run() {
 
 	w.SetFixedSize(true)
 
-	e.running.Store(true)
+	var budgetErr error
 
-	var wg sync.WaitGroup
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.app = a
+	e.running.Store(true)
+	e.loop.Go(func() {
+		cpuTicker := time.NewTicker(cpu.ClockRate())
+		defer cpuTicker.Stop()
 
-	wg.Go(func() {
+		// A recording still running when the emulator closes is written.
+		var rec *recording
 		defer func() {
-			_ = e.beep.Stop()
+			if rec != nil {
+				e.stopRecording(rec)
+			}
 		}()
 
-		cpuTicker := time.NewTicker(chip8.ClockRate)
-		defer cpuTicker.Stop()
+		// Draws are coalesced so the window is refreshed at most once per
+		// frame, however many sprites the ROM draws within it.
+		var (
+			pending     bool
+			lastRefresh time.Time
+		)
+
+		// Executed instructions are counted over one second windows.
+		var (
+			steps       uint64
+			windowStart = time.Now()
+			executed    uint64
+			wasPaused   bool
+			frameLeft   int  // Steps remaining in a frame advance.
+			midFrame    bool // Whether the last step was part of a frame advance.
+		)
 
 		for range cpuTicker.C {
 			if !e.running.Load() {
 				break
 			}
 
-			if e.paused.Load() {
-				if !e.next.Load() {
-					continue
+			var info uint8
+
+			if e.reset.Swap(false) {
+				// Reset also clears the display and releases all keys.
+				cpu.Reset()
+				cpu.Load(e.rom)
+				info |= chip8.Redraw
+			}
+
+			if e.save.Swap(false) {
+				if err := e.saveState(); err != nil {
+					e.warn("cannot save state", "file", e.SaveFile, "error", err)
+				}
+			}
+
+			if e.shot.Swap(false) {
+				name, err := e.screenshot()
+				if err != nil {
+					e.warn("cannot write screenshot", "error", err)
+				} else if e.Logger != nil {
+					e.Logger.Info("wrote screenshot", "file", name)
 				}
-				e.next.Store(false)
 			}
 
-			info := cpu.Step()
+			if e.record.Swap(false) {
+				if rec != nil {
+					e.stopRecording(rec)
+					rec = nil
+				} else if r, err := e.startRecording(); err != nil {
+					e.warn("cannot start recording", "error", err)
+				} else {
+					rec = r
+				}
+			}
+
+			if e.restore.Swap(false) {
+				ok, err := e.loadState()
+				switch {
+				case err != nil:
+					e.warn("cannot load state", "file", e.SaveFile, "error", err)
+				case !ok:
+					e.warn("no saved state to load", "file", e.SaveFile)
+				default:
+					info |= chip8.Redraw
+				}
+			}
+
+			if e.newRate.Swap(false) {
+				cpu.SetClockRate(time.Duration(e.rate.Load()))
+				if e.CycleCosts == nil {
+					cpuTicker.Reset(cpu.ClockRate())
+				}
+			}
+
+			step := true
+			paused := e.paused.Load()
+			if !paused {
+				frameLeft = 0
+			} else if e.frame.Swap(false) {
+				frameLeft = e.stepsPerFrame()
+			}
+
+			// A frame advance runs at the clock rate, timers included, so
+			// only its first step resumes the timers.
+			advancing := paused && frameLeft > 0
+			if advancing {
+				frameLeft--
+			} else if paused {
+				step = e.next.Swap(false)
+			}
+
+			// The timers stand still while paused, including across single
+			// steps, and pick up from the moment the emulator resumes.
+			if step && (paused || wasPaused) && !(advancing && midFrame) {
+				cpu.SyncTimers()
+			}
+			wasPaused = paused
+			midFrame = advancing
+
+			if elapsed := time.Since(windowStart); elapsed >= time.Second {
+				hz := uint64(float64(steps) / elapsed.Seconds())
+				e.hz.Store(hz)
+				if e.Logger != nil && !e.paused.Load() {
+					e.Logger.Info("measured clock rate", "hz", hz, "target", uint64(time.Second/cpu.ClockRate()))
+				}
+				steps, windowStart = 0, time.Now()
+			}
+
+			if step && e.MaxInstructions > 0 && executed >= e.MaxInstructions {
+				budgetErr = fmt.Errorf("%w after %d instructions", chip8.ErrCycleBudget, executed)
+				fyne.Do(a.Quit)
+				break
+			}
+
+			if step {
+				i, err := cpu.Step()
+				info |= i
+				steps++
+				executed++
+
+				if err != nil {
+					// Pause on the fault rather than crash, so the machine
+					// can still be inspected from the side panel.
+					e.warn("processor fault", "error", err)
+					e.paused.Store(true)
+					frameLeft = 0
+				}
+
+				if e.CycleCosts != nil {
+					// The next instruction starts once this one would have
+					// finished on the VIP.
+					cpuTicker.Reset(time.Duration(max(cpu.LastInstructionCost(), 1)) * chip8.VIPMachineCycle)
+				}
+			} else if info == 0 && !pending {
+				continue
+			}
 
 			for i := uint8(0); i <= 0xF; i++ {
 				registerName := byteconv.Btoh([]byte{i}, 1)
@@ -301,29 +937,60 @@ func (e *Emulator) Run() {
 				registerData.Update(int(i), label)
 			}
 
-			redraw := (info & chip8.Redraw) != 0
+			if (info & chip8.Redraw) != 0 {
+				if pending {
+					e.skipped.Add(1)
+				}
+				pending = true
+			}
+
+			redraw := pending && time.Since(lastRefresh) >= chip8.TimerRate
+			if redraw {
+				pending = false
+				lastRefresh = time.Now()
+			}
+
+			// A recording samples the display as often as the window shows
+			// it, so it only sees the frames a player would have seen.
+			if rec != nil {
+				var changed uint8
+				if redraw {
+					changed = chip8.Redraw
+				}
+				rec.gif.Sample(&cpu, changed, time.Now())
+			}
+
 			sound := (info & chip8.Sound) != 0
 
-			if sound {
-				_ = e.beep.Start(context.Background())
+			var err error
+			if sound && !e.Mute {
+				e.beep.SetPattern(cpu.AudioPattern())
+				err = e.beep.Start(context.Background())
 			} else {
-				_ = e.beep.Stop()
+				err = e.beep.Stop()
+			}
+			if err != nil {
+				e.warn("audio failure", "error", err)
 			}
 
+			// The window paints from its own copy of the display, so the CPU can
+			// keep drawing while the main goroutine renders the frame.
+			var (
+				frame         []byte
+				width, height int
+			)
 			if redraw {
-				for i, val := range cpu.Display() {
-					x, y := i%chip8.Width, i/chip8.Width
-					var c color.Color
-					c = color.Black
-					if val == 1 {
-						c = color.RGBA{R: 0, G: 255, B: 0, A: 255}
-					}
-					buffer.Set(x, y, c) // Directly sets pixels in the buffer
-				}
+				width, height = cpu.Dimensions()
+				frame = make([]byte, width*height)
+				cpu.CopyDisplay(frame)
 			}
 
-			opcode := cpu.OpcodeAt(cpu.ProgramCounter())
-			opcodeData.Prepend(opcode.String())
+			if step || info != 0 {
+				// Only a flush of a pending redraw runs without either.
+				if opcode, ok := cpu.PeekOpcode(); ok {
+					opcodeData.Prepend(mnemonic(opcode))
+				}
+			}
 
 			b := byteconv.U16tob(cpu.ProgramCounter())
 			h := byteconv.Btoh(b, 3)
@@ -342,7 +1009,13 @@ func (e *Emulator) Run() {
 			cpuData.Update(2, lblStackDepth)
 
 			fyne.Do(func() {
-				if redraw {
+				if frame != nil {
+					if width != bufferWidth {
+						bufferWidth = width
+						buffer = e.newBuffer(width, height)
+						image.Image = buffer
+					}
+					e.paint(buffer, frame, width)
 					image.Refresh()
 				}
 				opcodeData.Refresh()
@@ -354,7 +1027,40 @@ func (e *Emulator) Run() {
 		}
 	})
 
+	e.mu.Unlock()
+
 	w.ShowAndRun()
-	e.running.Store(false)
-	wg.Wait()
+
+	// The app has already quit, so Close must not ask it to again.
+	e.mu.Lock()
+	e.app = nil
+	e.mu.Unlock()
+
+	if err := e.Close(); err != nil {
+		e.warn("audio failure", "error", err)
+	}
+	return budgetErr
+}
+
+// Close stops the processor loop, releases the audio device, and quits the
+// window if it is open. It may be called from any goroutine, including while
+// Run is executing, but not from a fyne callback, since it waits for the loop
+// to stop. Calling it again does nothing and returns the error of the first
+// call. A closed emulator cannot be run again.
+func (e *Emulator) Close() error {
+	e.closeOnce.Do(func() {
+		e.mu.Lock()
+		e.closed = true
+		a := e.app
+		e.running.Store(false)
+		e.mu.Unlock()
+
+		e.loop.Wait()
+		e.closeErr = e.beep.Close()
+
+		if a != nil {
+			fyne.Do(a.Quit)
+		}
+	})
+	return e.closeErr
 }
@@ -6,7 +6,9 @@ require (
 	github.com/go-audio/audio v1.0.0
 	github.com/go-audio/generator v0.0.0-20191129013639-fe5438877d8c
 	github.com/gordonklaus/portaudio v0.0.0-20250206071425-98a94950218b
+	golang.org/x/net v0.35.0
 	golang.org/x/sync v0.19.0
+	golang.org/x/term v0.29.0
 )
 
 require (
@@ -39,7 +41,6 @@ require (
 	github.com/stretchr/testify v1.11.1 // indirect
 	github.com/yuin/goldmark v1.7.8 // indirect
 	golang.org/x/image v0.24.0 // indirect
-	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
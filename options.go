@@ -0,0 +1,78 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emul8
+
+import (
+	"emul8/chip8"
+	"image/color"
+	"time"
+)
+
+// Option configures the Emulator that RunROM creates.
+type Option func(*Emulator)
+
+// WithClockRate sets the interval between instructions.
+func WithClockRate(d time.Duration) Option {
+	return func(e *Emulator) { e.ClockRate = d }
+}
+
+// WithQuirks sets the quirks the processor emulates.
+func WithQuirks(q chip8.Quirks) Option {
+	return func(e *Emulator) { e.Quirks = q }
+}
+
+// WithXOChip runs the processor in XO-CHIP mode, which also lets RunROM load
+// ROMs of up to chip8.XOChipMaxROMSize bytes.
+func WithXOChip() Option {
+	return func(e *Emulator) { e.XOChip = true }
+}
+
+// WithRand sets the source of the random numbers of CXNN.
+func WithRand(r chip8.RandSource) Option {
+	return func(e *Emulator) { e.Rand = r }
+}
+
+// WithColors sets the colors of unlit and lit pixels.
+func WithColors(off, on color.Color) Option {
+	return func(e *Emulator) { e.Palette = Palette{off, on} }
+}
+
+// WithMute disables sound.
+func WithMute() Option {
+	return func(e *Emulator) { e.Mute = true }
+}
+
+// WithScale sets the number of window pixels per display pixel.
+func WithScale(n int) Option {
+	return func(e *Emulator) { e.Scale = n }
+}
+
+// RunROM creates an Emulator configured by opts, loads rom into it, and runs it
+// until the window is closed, returning the error of Load or Run. A ROM larger
+// than chip8.MaxROMSize only loads with WithXOChip. It is the whole of a
+// minimal front-end; programs that need more control use Load and Run.
+func RunROM(rom []byte, opts ...Option) error {
+	var e Emulator
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	if err := e.Load(rom); err != nil {
+		return err
+	}
+	return e.Run()
+}
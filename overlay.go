@@ -0,0 +1,94 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package emul8
+
+import (
+	"emul8/byteconv"
+	"emul8/chip8"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+var (
+	keyIdleColor = color.NRGBA{R: 0x30, G: 0x30, B: 0x30, A: 0xC0}
+	keyHeldColor = color.NRGBA{R: 0x00, G: 0x90, B: 0x00, A: 0xE0}
+	keyTextColor = color.White
+)
+
+// keypadOverlay draws chip8.Keypad over the display, each key labeled with its
+// hex digit and the host key that stands in for it, and highlights the keys
+// that are held. Its methods must be called on the fyne main goroutine.
+type keypadOverlay struct {
+	keys      [chip8.KeyCount]*canvas.Rectangle
+	container *fyne.Container
+}
+
+func newKeypadOverlay() *keypadOverlay {
+	o := &keypadOverlay{}
+
+	cells := make([]fyne.CanvasObject, 0, chip8.KeyCount)
+	for r, row := range chip8.Keypad {
+		for c, key := range row {
+			label := byteconv.Btoh([]byte{key}, 1) + " (" + string(hostKeys[r][c]) + ")"
+			text := canvas.NewText(label, keyTextColor)
+			text.Alignment = fyne.TextAlignCenter
+
+			o.keys[key] = canvas.NewRectangle(keyIdleColor)
+			cells = append(cells, container.NewStack(o.keys[key], container.NewCenter(text)))
+		}
+	}
+
+	o.container = container.NewGridWithColumns(len(chip8.Keypad[0]), cells...)
+	o.container.Hide()
+	return o
+}
+
+func (o *keypadOverlay) Object() fyne.CanvasObject {
+	return o.container
+}
+
+// Toggle shows the overlay if it is hidden, and hides it otherwise.
+func (o *keypadOverlay) Toggle(mask uint16) {
+	if o.container.Visible() {
+		o.container.Hide()
+		return
+	}
+	o.Refresh(mask)
+	o.container.Show()
+}
+
+// Refresh highlights the keys set in mask, as returned by
+// chip8.Processor.KeyMask. It does nothing while the overlay is hidden.
+func (o *keypadOverlay) Refresh(mask uint16) {
+	if !o.container.Visible() {
+		return
+	}
+
+	for key, rect := range o.keys {
+		fill := color.Color(keyIdleColor)
+		if mask&(1<<key) != 0 {
+			fill = keyHeldColor
+		}
+		if rect.FillColor != fill {
+			rect.FillColor = fill
+			rect.Refresh()
+		}
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package romfile finds and reads ROM files on disk, decompressing them as
+// needed. It is independent of the GUI and audio front-end.
+package romfile
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"emul8/chip8"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var gzipMagic = []byte{0x1F, 0x8B}
+
+// romExtensions are the file extensions ScanDir considers, lower case. Any of
+// them may be followed by .gz.
+var romExtensions = []string{".ch8", ".c8", ".sc8", ".xo8"}
+
+// Info describes a ROM found by ScanDir.
+type Info struct {
+	Path     string        // location of the file, including the scanned directory
+	Size     int           // size of the ROM, after any decompression
+	Hash     string        // chip8.Fingerprint of the ROM
+	Platform chip8.Profile // chip8.Detect's guess at the platform
+}
+
+// Load reads the ROM at path. Files starting with the gzip magic bytes are
+// decompressed transparently, whatever their name. A ROM larger than
+// chip8.XOChipMaxROMSize, which no mode can load, returns an error wrapping
+// chip8.ErrROMTooLarge; a compressed one is not decompressed past that.
+func Load(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(b, gzipMagic) {
+		if len(b) > chip8.XOChipMaxROMSize {
+			return nil, tooLarge(path)
+		}
+		return b, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	rom, err := io.ReadAll(io.LimitReader(r, int64(chip8.XOChipMaxROMSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rom) > chip8.XOChipMaxROMSize {
+		return nil, tooLarge(path)
+	}
+	return rom, nil
+}
+
+func tooLarge(path string) error {
+	return fmt.Errorf("%s: %w: more than %d bytes", path, chip8.ErrROMTooLarge, chip8.XOChipMaxROMSize)
+}
+
+// ScanDir walks the directory tree at path and describes every ROM in it,
+// sorted by path, without loading any of them into a processor. Files are
+// recognized by extension. Those that cannot be read, or that are empty or too
+// large to load even in XO-CHIP mode, are skipped. Only a failure to walk the
+// tree is returned as an error.
+func ScanDir(path string) ([]Info, error) {
+	var roms []Info
+
+	err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() || !isROMName(name) {
+			return nil
+		}
+
+		rom, err := Load(name)
+		if err != nil || len(rom) == 0 {
+			return nil
+		}
+
+		roms = append(roms, Info{
+			Path:     name,
+			Size:     len(rom),
+			Hash:     chip8.Fingerprint(rom),
+			Platform: chip8.Detect(rom),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(roms, func(a, b Info) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	return roms, nil
+}
+
+func isROMName(name string) bool {
+	ext := strings.TrimSuffix(strings.ToLower(name), ".gz")
+	return slices.Contains(romExtensions, filepath.Ext(ext))
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package romfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"emul8/chip8"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// compress returns b gzipped.
+func compress(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	rom := []byte{0x00, 0xE0, 0x12, 0x00}
+	full := bytes.Repeat([]byte{0xAB}, chip8.XOChipMaxROMSize)
+	gz := compress(t, rom)
+
+	tests := []struct {
+		name    string
+		file    []byte
+		want    []byte
+		wantErr error
+	}{
+		{"plain", rom, rom, nil},
+		{"gzip", gz, rom, nil},
+		{"truncated gzip", gz[:len(gz)-6], nil, io.ErrUnexpectedEOF},
+		{"largest plain", full, full, nil},
+		{"largest gzip", compress(t, full), full, nil},
+		{"plain too large", append(full, 0), nil, chip8.ErrROMTooLarge},
+		{"gzip too large", compress(t, append(full, 0)), nil, chip8.ErrROMTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The name does not decide whether the file is decompressed.
+			path := filepath.Join(t.TempDir(), "rom.ch8")
+			if err := os.WriteFile(path, tt.file, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Load(path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Load() = %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	rom := []byte{0x00, 0xE0, 0x12, 0x00}
+	gz := compress(t, rom)
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"b.ch8":         rom,
+		"sub/c.xo8.gz":  gz,
+		"UPPER.SC8":     rom,
+		"notes.txt":     rom,
+		"empty.ch8":     nil,
+		"large.ch8":     make([]byte, chip8.XOChipMaxROMSize+1),
+		"broken.c8.gz":  gz[:len(gz)-6],
+		"sub/rom.ch8.d": rom,
+	}
+	for name, b := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir.ch8"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	roms, err := ScanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, info := range roms {
+		paths = append(paths, info.Path)
+		if info.Size != len(rom) || info.Hash != chip8.Fingerprint(rom) || info.Platform != chip8.Detect(rom) {
+			t.Errorf("%s: %+v, want the ROM of %d bytes", info.Path, info, len(rom))
+		}
+	}
+	want := []string{
+		filepath.Join(dir, "UPPER.SC8"),
+		filepath.Join(dir, "b.ch8"),
+		filepath.Join(dir, "sub/c.xo8.gz"),
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("ScanDir() = %q, want %q", paths, want)
+	}
+}
+
+func TestScanDirMissing(t *testing.T) {
+	if _, err := ScanDir(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error %v, want os.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package server exposes a chip8.Processor over HTTP, for remote debugging and
+// integration tests. It is independent of the GUI and audio front-end.
+//
+// The endpoints are:
+//
+//	POST /load             load the request body as a ROM, after a reset
+//	POST /reset            reset and reload the last ROM
+//	POST /step?n=N         execute N instructions (default 1, at most 1000000)
+//	POST /key?key=K&down=B set key K (hex) to held (true) or released (false)
+//	GET  /registers        PC, I, SP, and V0 through VF as JSON
+//	GET  /memory?addr=A&len=N  N bytes of memory at A (hex) as octet-stream
+//	GET  /screenshot.png?scale=S  the display as a PNG, scaled S times, at its
+//	                       active resolution
+//	GET  /stream           run the processor, streaming frames over a WebSocket
+//
+// Steps that fault, for instance on an unknown opcode, or that exceed the
+// instruction budget are reported with status 422 and leave the processor
+// where the fault occurred.
+package server
+
+import (
+	"bytes"
+	"emul8/chip8"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// maxScale bounds the screenshot scale factor, and with it the image size.
+const maxScale int = 32
+
+// maxSteps bounds how many instructions one POST /step executes, since the
+// processor is locked meanwhile, and every other endpoint waits on it.
+const maxSteps int = 1_000_000
+
+type Server struct {
+	// ClockRate is the interval between instructions while a stream is open.
+	// Zero means chip8.ClockRate.
+	ClockRate time.Duration
+
+	// MaxInstructions, when nonzero, limits how many instructions may be
+	// executed after each load or reset. Once it is spent, steps fail with an
+	// error wrapping chip8.ErrCycleBudget, so an untrusted ROM cannot run
+	// forever.
+	MaxInstructions uint64
+
+	mu        sync.Mutex
+	executed  uint64
+	cpu       chip8.Processor
+	rom       []byte
+	mux       *http.ServeMux
+	streaming atomic.Bool
+}
+
+// Registers is the body returned by GET /registers.
+type Registers struct {
+	PC uint16                    `json:"pc"`
+	I  uint16                    `json:"i"`
+	SP int                       `json:"sp"`
+	V  [chip8.RegisterCount]byte `json:"v"`
+}
+
+func New() *Server {
+	s := &Server{
+		mux: http.NewServeMux(),
+	}
+	s.cpu.Reset()
+
+	s.mux.HandleFunc("POST /load", s.load)
+	s.mux.HandleFunc("POST /reset", s.reset)
+	s.mux.HandleFunc("POST /step", s.step)
+	s.mux.HandleFunc("POST /key", s.key)
+	s.mux.HandleFunc("GET /registers", s.registers)
+	s.mux.HandleFunc("GET /memory", s.memory)
+	s.mux.HandleFunc("GET /screenshot.png", s.screenshot)
+	s.mux.Handle("GET /stream", websocket.Handler(s.stream))
+	return s
+}
+
+// SetQuirks selects the quirks the processor emulates. They survive resets.
+func (s *Server) SetQuirks(q chip8.Quirks) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cpu.SetQuirks(q)
+}
+
+// SetXOChip turns the XO-CHIP mode of the processor on or off. It survives
+// resets.
+func (s *Server) SetXOChip(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cpu.XOChip = on
+}
+
+// Load resets the processor and loads rom, as POST /load does. A ROM too large
+// for the current mode is refused with an error wrapping chip8.ErrROMTooLarge,
+// leaving the loaded program as it was.
+func (s *Server) Load(rom []byte) error {
+	return s.do(func() {
+		if limit := s.romLimit(); len(rom) > limit {
+			panic(fmt.Errorf("%w: %d bytes, at most %d fit", chip8.ErrROMTooLarge, len(rom), limit))
+		}
+		s.rom = rom
+		s.executed = 0
+		s.cpu.Reset()
+		s.cpu.Load(rom)
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func hexParam(r *http.Request, name string, def uint16) (uint16, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return def, nil
+	}
+
+	v, err := strconv.ParseUint(str, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, str)
+	}
+	return uint16(v), nil
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	str := r.URL.Query().Get(name)
+	if str == "" {
+		return def, nil
+	}
+
+	v, err := strconv.Atoi(str)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid %s %q", name, str)
+	}
+	return v, nil
+}
+
+// do runs fn with the processor locked, converting a processor panic into an
+// error, which matches the chip8 sentinel errors with errors.Is.
+func (s *Server) do(fn func()) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	defer func() {
+		// Faults are errors wrapping the chip8 sentinels, which already say
+		// where they happened. Anything else gets the program counter.
+		switch r := recover().(type) {
+		case nil:
+		case runtime.Error:
+			err = fmt.Errorf("%w at %03X", r, s.cpu.ProgramCounter())
+		case error:
+			err = r
+		default:
+			err = fmt.Errorf("%v at %03X", r, s.cpu.ProgramCounter())
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+// stepOnce executes one instruction, unless the budget is spent, returning its
+// fault if it has one. The caller must hold the lock.
+func (s *Server) stepOnce() error {
+	if s.MaxInstructions > 0 && s.executed >= s.MaxInstructions {
+		return fmt.Errorf("%w after %d instructions", chip8.ErrCycleBudget, s.executed)
+	}
+
+	s.executed++
+	_, err := s.cpu.Step()
+	return err
+}
+
+// maxROMSize returns the size of the largest ROM the processor accepts in its
+// current mode.
+func (s *Server) maxROMSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.romLimit()
+}
+
+// romLimit is maxROMSize for a caller that holds the lock.
+func (s *Server) romLimit() int {
+	if s.cpu.XOChip {
+		return chip8.XOChipMaxROMSize
+	}
+	return chip8.MaxROMSize
+}
+
+func (s *Server) load(w http.ResponseWriter, r *http.Request) {
+	limit := s.maxROMSize()
+	rom, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(rom) > limit {
+		http.Error(w, "rom too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The mode can change between the check above and the load.
+	if err := s.Load(rom); err != nil {
+		http.Error(w, "rom too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) reset(w http.ResponseWriter, r *http.Request) {
+	_ = s.do(func() {
+		s.executed = 0
+		s.cpu.Reset()
+		s.cpu.Load(s.rom)
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) step(w http.ResponseWriter, r *http.Request) {
+	n, err := intParam(r, "n", 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if n > maxSteps {
+		http.Error(w, fmt.Sprintf("n exceeds %d", maxSteps), http.StatusBadRequest)
+		return
+	}
+
+	var stepErr error
+	err = s.do(func() {
+		// Time between requests must not count against the timers.
+		s.cpu.SyncTimers()
+		for range n {
+			if stepErr = s.stepOnce(); stepErr != nil {
+				return
+			}
+		}
+	})
+	if err == nil {
+		err = stepErr
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	s.registers(w, r)
+}
+
+func (s *Server) key(w http.ResponseWriter, r *http.Request) {
+	key, err := hexParam(r, "key", 0)
+	if err != nil || key > 0xF {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+
+	down, err := strconv.ParseBool(r.URL.Query().Get("down"))
+	if err != nil {
+		http.Error(w, "invalid down", http.StatusBadRequest)
+		return
+	}
+
+	// SetKey is safe to call concurrently with a step.
+	s.cpu.SetKey(uint8(key), down)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) registers(w http.ResponseWriter, r *http.Request) {
+	var regs Registers
+
+	_ = s.do(func() {
+		regs.PC = s.cpu.ProgramCounter()
+		regs.I = s.cpu.Index()
+		regs.SP = s.cpu.StackDepth()
+		for i := range regs.V {
+			regs.V[i] = s.cpu.Register(uint8(i))
+		}
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(regs)
+}
+
+func (s *Server) memory(w http.ResponseWriter, r *http.Request) {
+	addr, err := hexParam(r, "addr", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := hexParam(r, "len", 0x10)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := make([]byte, n)
+	_ = s.do(func() {
+		data = data[:s.cpu.Read(addr, data)]
+	})
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+func (s *Server) screenshot(w http.ResponseWriter, r *http.Request) {
+	scale, err := intParam(r, "scale", 1)
+	if err != nil || scale > maxScale {
+		http.Error(w, "invalid scale", http.StatusBadRequest)
+		return
+	}
+
+	var img image.Image
+	_ = s.do(func() {
+		img = s.cpu.ScaledFrame(scale)
+	})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2026 Joshua Jones <joshua.jones.software@gmail.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      www.apache.org
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"emul8/chip8"
+	"slices"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// packDisplay packs display into dst at one bit per pixel, row-major, with the
+// leftmost pixel of each byte in its most significant bit, and returns the
+// packed frame, which reuses dst if it is large enough.
+func packDisplay(dst []byte, display []byte) []byte {
+	dst = slices.Grow(dst[:0], len(display)/8)[:len(display)/8]
+	clear(dst)
+	for i, val := range display {
+		if val != 0 {
+			dst[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return dst
+}
+
+func (s *Server) clockRate() time.Duration {
+	if s.ClockRate > 0 {
+		return s.ClockRate
+	}
+	return chip8.ClockRate
+}
+
+// stream runs the processor for as long as the WebSocket is open. Whenever the
+// display changes, at most once per TimerRate, the bit-packed frame is sent as
+// a binary message. A whole frame is only 256 bytes, or 1024 in SUPER-CHIP's
+// high resolution mode, which a client can tell from the length, so no attempt
+// is made to send partial updates.
+//
+// The client sends two-byte binary messages, the key followed by 1 for down or
+// 0 for up. A fault is reported in a text message before the stream closes.
+func (s *Server) stream(ws *websocket.Conn) {
+	if !s.streaming.CompareAndSwap(false, true) {
+		_ = websocket.Message.Send(ws, "stream already open")
+		return
+	}
+	defer s.streaming.Store(false)
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if len(msg) == 2 && int(msg[0]) < chip8.KeyCount {
+				s.cpu.SetKey(msg[0], msg[1] != 0)
+			}
+		}
+	}()
+
+	_ = s.do(s.cpu.SyncTimers)
+
+	ticker := time.NewTicker(s.clockRate())
+	defer ticker.Stop()
+
+	var frame, sent []byte
+	var lastFrame time.Time
+	first := true
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		refresh := first || now.Sub(lastFrame) >= chip8.TimerRate
+
+		var stepErr error
+		err := s.do(func() {
+			stepErr = s.stepOnce()
+			if refresh {
+				frame = packDisplay(frame, s.cpu.Display())
+			}
+		})
+		if err == nil {
+			err = stepErr
+		}
+		if err != nil {
+			_ = websocket.Message.Send(ws, err.Error())
+			return
+		}
+
+		if !refresh {
+			continue
+		}
+		lastFrame = now
+
+		if first || !bytes.Equal(frame, sent) {
+			if err := websocket.Message.Send(ws, frame); err != nil {
+				return
+			}
+			sent, first = append(sent[:0], frame...), false
+		}
+	}
+}